@@ -0,0 +1,124 @@
+// Package ar reads the members of static archives in the common System V
+// (GNU) and BSD formats, as written by ar and the Go toolchain.
+package ar
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Magic is the start of every archive.
+const Magic = "!<arch>\n"
+
+const headerSize = 60
+
+// Member is a file stored in an archive.
+type Member struct {
+	// Name is the member file name. If several members share a name, the
+	// later ones get a "#2", "#3", ... suffix so they can be told apart.
+	Name   string
+	Offset int64 // offset of the member data in the archive
+	Size   int64
+}
+
+func (m Member) IsEmpty() bool {
+	return len(m.Name) == 0 && m.Size == 0
+}
+
+// IsArchive reports whether r starts with the archive magic.
+func IsArchive(r io.ReaderAt) bool {
+	var magic [len(Magic)]byte
+	_, err := r.ReadAt(magic[:], 0)
+	return err == nil && string(magic[:]) == Magic
+}
+
+var errTruncated = errors.New("ar: truncated archive")
+
+// List returns the members of the archive in r. The symbol index and long
+// name table maintained by ar itself are not returned.
+func List(r io.ReaderAt) ([]Member, error) {
+	if !IsArchive(r) {
+		return nil, errors.New("ar: not an archive")
+	}
+	var ret []Member
+	var longNames []byte
+	seen := map[string]int{}
+	off := int64(len(Magic))
+	for {
+		var hdr [headerSize]byte
+		n, err := r.ReadAt(hdr[:], off)
+		if n == 0 && err == io.EOF {
+			break
+		}
+		if n != headerSize {
+			return nil, errTruncated
+		}
+		if string(hdr[58:60]) != "`\n" {
+			return nil, fmt.Errorf("ar: bad member header at offset %d", off)
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(string(hdr[48:58])), 10, 64)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("ar: bad member size at offset %d", off)
+		}
+		name := strings.TrimRight(string(hdr[0:16]), " ")
+		data := off + headerSize
+
+		switch {
+		case name == "//":
+			// GNU long name table
+			longNames = make([]byte, size)
+			if _, err := r.ReadAt(longNames, data); err != nil {
+				return nil, errTruncated
+			}
+			name = ""
+		case name == "/" || name == "/SYM64/" || strings.HasPrefix(name, "__.SYMDEF"):
+			// symbol index
+			name = ""
+		case strings.HasPrefix(name, "#1/"):
+			// BSD long name, stored in front of the data
+			n, err := strconv.Atoi(name[3:])
+			if err != nil || int64(n) > size {
+				return nil, fmt.Errorf("ar: bad member name %q", name)
+			}
+			buf := make([]byte, n)
+			if _, err := r.ReadAt(buf, data); err != nil {
+				return nil, errTruncated
+			}
+			name = string(bytes.TrimRight(buf, "\x00"))
+			data += int64(n)
+			size -= int64(n)
+		case strings.HasPrefix(name, "/"):
+			// GNU long name, an offset into the long name table
+			i, err := strconv.Atoi(name[1:])
+			if err != nil || i >= len(longNames) {
+				return nil, fmt.Errorf("ar: bad member name %q", name)
+			}
+			name = string(longNames[i:])
+			if end := strings.Index(name, "/\n"); end >= 0 {
+				name = name[:end]
+			}
+		default:
+			name = strings.TrimSuffix(name, "/")
+		}
+
+		if name != "" {
+			seen[name]++
+			if seen[name] > 1 {
+				name = fmt.Sprintf("%s#%d", name, seen[name])
+			}
+			ret = append(ret, Member{Name: name, Offset: data, Size: size})
+		}
+		// member data is padded to an even offset
+		off = data + size + (data+size)%2
+	}
+	return ret, nil
+}
+
+// Open returns a reader for the data of member m of the archive in r.
+func Open(r io.ReaderAt, m Member) *io.SectionReader {
+	return io.NewSectionReader(r, m.Offset, m.Size)
+}
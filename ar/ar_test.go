@@ -0,0 +1,84 @@
+package ar
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func header(name string, size int) string {
+	return fmt.Sprintf("%-16s%-12d%-6d%-6d%-8o%-10d`\n", name, 0, 0, 0, 0644, size)
+}
+
+func member(name, data string) string {
+	s := header(name, len(data)) + data
+	if len(data)%2 != 0 {
+		s += "\n"
+	}
+	return s
+}
+
+func TestListGNU(t *testing.T) {
+	longNames := "a_very_long_object_name.o/\n"
+	archive := Magic +
+		member("/", "\x00\x00\x00\x00") +
+		member("//", longNames) +
+		member("short.o/", "abc") +
+		member("/0", "long data") +
+		member("short.o/", "second")
+
+	r := bytes.NewReader([]byte(archive))
+	mems, err := List(r)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	exp := []struct{ name, data string }{
+		{"short.o", "abc"},
+		{"a_very_long_object_name.o", "long data"},
+		{"short.o#2", "second"},
+	}
+	if len(mems) != len(exp) {
+		t.Fatalf("expected %d members, got %v", len(exp), mems)
+	}
+	for i, e := range exp {
+		if mems[i].Name != e.name {
+			t.Errorf("expected member %s, got %s", e.name, mems[i].Name)
+		}
+		data, _ := io.ReadAll(Open(r, mems[i]))
+		if string(data) != e.data {
+			t.Errorf("expected %q in %s, got %q", e.data, e.name, data)
+		}
+	}
+}
+
+func TestListBSD(t *testing.T) {
+	name := "long_bsd_name.o\x00"
+	archive := Magic +
+		member("__.SYMDEF SORTED", "\x00\x00\x00\x00") +
+		member(fmt.Sprintf("#1/%d", len(name)), name+"payload")
+
+	r := bytes.NewReader([]byte(archive))
+	mems, err := List(r)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(mems) != 1 || mems[0].Name != "long_bsd_name.o" || mems[0].Size != 7 {
+		t.Fatalf("unexpected members %v", mems)
+	}
+	data, _ := io.ReadAll(Open(r, mems[0]))
+	if string(data) != "payload" {
+		t.Errorf("expected payload, got %q", data)
+	}
+}
+
+func TestListTruncated(t *testing.T) {
+	archive := Magic + header("a.o/", 10) + "short"
+	mems, err := List(bytes.NewReader([]byte(archive[:len(Magic)+30])))
+	if err == nil {
+		t.Errorf("expected an error, got %v", mems)
+	}
+	if IsArchive(bytes.NewReader([]byte("\x7fELF"))) {
+		t.Errorf("expected ELF not to be an archive")
+	}
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/tzneal/bincmp/cmp"
+	"github.com/tzneal/bincmp/nm"
 )
 
 func main() {
@@ -15,6 +18,16 @@ func main() {
 	noColor := flag.Bool("no-color", false, "force disable of color output")
 	forceColor := flag.Bool("color", false, "force color output, regardless of terminal")
 	noSymTab := flag.Bool("no-symtab", false, "only show section size difs")
+	arch := flag.String("arch", "", "architecture to compare in Mach-O universal binaries")
+	abi := flag.Bool("abi", false, "only compare exported symbols, exit with status 1 if any were removed")
+	changed := flag.Bool("changed", false, "also report symbols and sections whose contents changed but not their size")
+	noDemangle := flag.Bool("no-demangle", false, "show C++ and Rust symbols by their mangled names")
+	by := flag.String("by", "", "report symbol sizes grouped by "+strings.Join(cmp.Groupings(), ", ")+" instead of per symbol")
+	tree := flag.Bool("tree", false, "report symbol sizes as a tree of package path segments, or of the -by grouping")
+	depth := flag.Int("depth", 0, "collapse the -tree report below this many levels, 0 shows all levels")
+	minDelta := flag.Int64("min-delta", 0, "leave nodes that changed by fewer bytes out of the -tree report")
+	inlining := flag.Bool("inlining", false, "report the calls each function stopped or started inlining, from the DWARF info")
+	kinds := flag.String("type", "", "comma separated symbol kinds to report (text, data, rodata, bss, tls, undefined, absolute, common, debug)")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
@@ -42,13 +55,60 @@ func main() {
 		Pattern:     *pattern,
 		Writer:      cmp.DefaultWriter,
 		Disassemble: *disassemble,
+		Arch:        *arch,
+		Demangle:    !*noDemangle,
+		Changed:     *changed,
+	}
+	if *by != "" {
+		if err := cmp.ValidGrouping(*by); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if *kinds != "" {
+		for _, k := range strings.Split(*kinds, ",") {
+			kind, err := nm.ParseKind(strings.TrimSpace(k))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			opts.Kinds = append(opts.Kinds, kind)
+		}
+	}
+	c := cmp.NewComparer(flag.Arg(0), flag.Arg(1), opts)
+	if *abi {
+		if err := c.CompareExports(); err != nil {
+			if !errors.Is(err, cmp.ErrExportsRemoved) {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+	c.CompareFiles()
+	c.CompareBuildInfo()
+	c.CompareMembers()
+	switch {
+	case *noSymTab:
+	case *tree:
+		if *by == "" {
+			*by = "package"
+		}
+		c.CompareTree(*by, *depth, *minDelta)
+	case *by != "":
+		c.CompareGroups(*by)
+	default:
+		c.CompareSymbols()
 	}
-	cmp := cmp.NewComparer(flag.Arg(0), flag.Arg(1), opts)
-	cmp.CompareFiles()
-	fmt.Println()
 	if !*noSymTab {
-		cmp.CompareSymbols()
-		fmt.Println()
+		c.CompareStrings()
+	}
+	c.CompareEmbeds()
+	if *inlining {
+		c.CompareInlining()
 	}
-	cmp.CompareSections()
+	c.CompareSections()
+	c.CompareImports()
+	c.CompareVersions()
+	c.CompareExports()
 }
@@ -0,0 +1,61 @@
+package cmp
+
+import (
+	"debug/buildinfo"
+	"runtime/debug"
+	"strings"
+)
+
+// BuildSetting is one item of the build info the Go linker embeds in a
+// binary: the Go version, the main module, a build setting or a dependency.
+type BuildSetting struct {
+	Key   string
+	Value string
+}
+
+func (b BuildSetting) IsEmpty() bool {
+	return b.Key == ""
+}
+
+// listBuildSettings returns the build info of a Go binary, or nil if the
+// file isn't one. Keys are "go" for the Go version, "path" for the main
+// package, "mod" for the main module, the build setting names like
+// "-ldflags", "CGO_ENABLED" or "vcs.revision", and "dep" followed by the
+// module path for dependencies.
+func listBuildSettings(filename string) []BuildSetting {
+	bi, err := buildinfo.ReadFile(filename)
+	if err != nil {
+		return nil
+	}
+	return buildSettings(bi)
+}
+
+// buildSettings returns the settings of listBuildSettings for the build
+// info bi.
+func buildSettings(bi *debug.BuildInfo) []BuildSetting {
+	ret := []BuildSetting{
+		{Key: "go", Value: bi.GoVersion},
+		{Key: "path", Value: bi.Path},
+		{Key: "mod", Value: strings.TrimSpace(bi.Main.Path + " " + moduleString(&bi.Main))},
+	}
+	for _, s := range bi.Settings {
+		ret = append(ret, BuildSetting{Key: s.Key, Value: s.Value})
+	}
+	for _, d := range bi.Deps {
+		ret = append(ret, BuildSetting{Key: "dep " + d.Path, Value: moduleString(d)})
+	}
+	return ret
+}
+
+// moduleString describes the version of a module and its checksum.
+func moduleString(m *debug.Module) string {
+	s := moduleVersion(m)
+	if m.Replace != nil {
+		s = "=> " + s
+		m = m.Replace
+	}
+	if m.Sum != "" {
+		s += " " + m.Sum
+	}
+	return s
+}
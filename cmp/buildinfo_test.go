@@ -0,0 +1,79 @@
+package cmp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime/debug"
+	"testing"
+)
+
+func TestBuildSettings(t *testing.T) {
+	a := buildSettings(&debug.BuildInfo{
+		GoVersion: "go1.22.1",
+		Path:      "example.com/app/cmd/app",
+		Main:      debug.Module{Path: "example.com/app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "golang.org/x/sys", Version: "v0.16.0", Sum: "h1:sys16"},
+			{Path: "golang.org/x/net", Version: "v0.20.0", Sum: "h1:net20"},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "-ldflags", Value: "-s -w"},
+			{Key: "CGO_ENABLED", Value: "1"},
+			{Key: "vcs.revision", Value: "abc"},
+		},
+	})
+	b := buildSettings(&debug.BuildInfo{
+		GoVersion: "go1.22.2",
+		Path:      "example.com/app/cmd/app",
+		Main:      debug.Module{Path: "example.com/app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "golang.org/x/net", Version: "v0.20.0", Sum: "h1:net20",
+				Replace: &debug.Module{Path: "github.com/fork/net", Version: "v0.20.1", Sum: "h1:fork"}},
+			{Path: "github.com/new/dep", Version: "v1.0.0", Sum: "h1:dep"},
+			{Path: "golang.org/x/text", Version: "v0.14.0", Sum: "h1:text",
+				Replace: &debug.Module{Path: "../text"}},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "-tags", Value: "netgo"},
+			{Key: "CGO_ENABLED", Value: "0"},
+			{Key: "vcs.revision", Value: "def"},
+		},
+	})
+
+	aKnown, bKnown, keys := uniqSettingKeys(a, b)
+	expKeys := []string{"go", "path", "mod", "-ldflags", "CGO_ENABLED", "vcs.revision", "-tags",
+		"dep github.com/new/dep", "dep golang.org/x/net", "dep golang.org/x/sys", "dep golang.org/x/text"}
+	if !reflect.DeepEqual(keys, expKeys) {
+		t.Errorf("expected keys %v, got %v", expKeys, keys)
+	}
+	for _, tc := range []struct {
+		key  string
+		a, b string
+	}{
+		{"go", "go1.22.1", "go1.22.2"},
+		{"mod", "example.com/app (devel)", "example.com/app (devel)"},
+		{"-ldflags", "-s -w", ""},
+		{"-tags", "", "netgo"},
+		{"dep golang.org/x/sys", "v0.16.0 h1:sys16", ""},
+		{"dep golang.org/x/net", "v0.20.0 h1:net20", "=> github.com/fork/net@v0.20.1 h1:fork"},
+		{"dep golang.org/x/text", "", "=> ../text"},
+	} {
+		if got := aKnown[tc.key].Value; got != tc.a {
+			t.Errorf("%s: expected old value %q, got %q", tc.key, tc.a, got)
+		}
+		if got := bKnown[tc.key].Value; got != tc.b {
+			t.Errorf("%s: expected new value %q, got %q", tc.key, tc.b, got)
+		}
+	}
+}
+
+func TestListBuildSettingsNotGo(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "notgo")
+	if err := os.WriteFile(name, []byte("#!/bin/sh\necho hello\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if settings := listBuildSettings(name); settings != nil {
+		t.Errorf("expected no build info, got %v", settings)
+	}
+}
@@ -0,0 +1,157 @@
+package cmp
+
+import (
+	"sort"
+
+	"github.com/tzneal/bincmp/internal/objfile"
+	"github.com/tzneal/bincmp/nm"
+	"github.com/tzneal/bincmp/objdump"
+	"github.com/tzneal/bincmp/readelf"
+)
+
+// codeComparer compares the code of functions by their normalized
+// disassembly, so that functions that only moved, or call functions that
+// moved, compare equal. Both binaries are disassembled once, the first time
+// it's needed.
+type codeComparer struct {
+	fileA, fileB string
+	arch         string
+	aSyms, bSyms []nm.Symbol
+
+	lookupA, lookupB objdump.Lookup
+	goarchA, goarchB string
+	loaded           bool
+	fnsA, fnsB       map[string]objdump.Function
+}
+
+func newCodeComparer(fileA, fileB, arch string, aSyms, bSyms []nm.Symbol) *codeComparer {
+	return &codeComparer{fileA: fileA, fileB: fileB, arch: arch, aSyms: aSyms, bSyms: bSyms}
+}
+
+// lookups builds the address lookups of both binaries, and finds the
+// architecture of their code, the first time they are needed.
+func (cc *codeComparer) lookups() {
+	if cc.lookupA != nil {
+		return
+	}
+	// sections are only needed for addresses outside of any symbol
+	aSects, _ := readelf.ListSectionsArch(cc.fileA, cc.arch)
+	bSects, _ := readelf.ListSectionsArch(cc.fileB, cc.arch)
+	cc.lookupA = symbolLookup(cc.aSyms, aSects)
+	cc.lookupB = symbolLookup(cc.bSyms, bSects)
+	cc.goarchA = goarch(cc.fileA, cc.arch)
+	cc.goarchB = goarch(cc.fileB, cc.arch)
+}
+
+// goarch returns the Go name of the architecture of a binary's code, or ""
+// if it can't be read.
+func goarch(filename, arch string) string {
+	f, err := objfile.Open(filename, arch)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	return f.GOARCH()
+}
+
+// sameCode reports whether two functions have the same normalized code. It
+// is false if they can't be disassembled, e.g. because the go tool isn't
+// installed.
+func (cc *codeComparer) sameCode(symA, symB nm.Symbol) bool {
+	if symA.Kind != nm.KindText || symB.Kind != nm.KindText || symA.Member != "" || symB.Member != "" {
+		return false
+	}
+	if !cc.loaded {
+		cc.loaded = true
+		cc.lookups()
+		cc.fnsA = disassembleAll(cc.fileA, cc.goarchA, cc.lookupA)
+		cc.fnsB = disassembleAll(cc.fileB, cc.goarchB, cc.lookupB)
+	}
+	fnA, okA := cc.fnsA[symA.Name]
+	fnB, okB := cc.fnsB[symB.Name]
+	return okA && okB && objdump.SameCode(fnA, fnB)
+}
+
+// functions disassembles a function of each binary for WriteDisassembly.
+// Either may be empty if the function doesn't exist in that binary.
+func (cc *codeComparer) functions(nameA, nameB string) (objdump.Function, objdump.Function, error) {
+	fnA, err := objdump.DisassembleFunction(cc.fileA, nameA)
+	if err != nil && !objdump.IsNotFound(err) {
+		return fnA, fnA, err
+	}
+	fnB, err := objdump.DisassembleFunction(cc.fileB, nameB)
+	if err != nil && !objdump.IsNotFound(err) {
+		return fnA, fnB, err
+	}
+	cc.lookups()
+	fnA.Normalize(cc.goarchA, cc.lookupA)
+	fnB.Normalize(cc.goarchB, cc.lookupB)
+	return fnA, fnB, nil
+}
+
+func disassembleAll(filename, goarch string, lookup objdump.Lookup) map[string]objdump.Function {
+	fns, err := objdump.Disassemble(filename)
+	if err != nil {
+		return nil
+	}
+	ret := make(map[string]objdump.Function, len(fns))
+	for _, fn := range fns {
+		fn.Normalize(goarch, lookup)
+		ret[fn.Name] = fn
+	}
+	return ret
+}
+
+// symbolLookup returns an objdump.Lookup finding addresses in the defined
+// symbols of a binary. Symbols without a size, like the type:* and
+// go:string.* symbols Go binaries lay out their type descriptors and
+// strings under, extend to the next symbol or the end of their section.
+// Of symbols at the same address the largest is picked, or else the first
+// by name. Addresses outside of any symbol, like those of anonymous
+// constants, are found in their section.
+func symbolLookup(syms []nm.Symbol, sects []readelf.Section) objdump.Lookup {
+	var defined []nm.Symbol
+	for _, s := range syms {
+		if s.Section != 0 && s.Member == "" && s.Kind != nm.KindAbsolute && s.Kind != nm.KindDebug {
+			defined = append(defined, s)
+		}
+	}
+	sort.Slice(defined, func(i, j int) bool {
+		a, b := defined[i], defined[j]
+		if a.Value != b.Value {
+			return a.Value < b.Value
+		}
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		return a.Name < b.Name
+	})
+	uniq := defined[:0]
+	for _, s := range defined {
+		if len(uniq) == 0 || uniq[len(uniq)-1].Value != s.Value {
+			uniq = append(uniq, s)
+		}
+	}
+	return func(addr int64) (string, int64, bool) {
+		var sect *readelf.Section
+		for i := range sects {
+			if sects[i].Address != 0 && addr >= sects[i].Address && addr < sects[i].Address+sects[i].Size {
+				sect = &sects[i]
+				break
+			}
+		}
+		i := sort.Search(len(uniq), func(i int) bool {
+			return uniq[i].Value > addr
+		}) - 1
+		if i >= 0 {
+			s := uniq[i]
+			if addr < s.Value+s.Size || s.Size == 0 && sect != nil && s.Value >= sect.Address {
+				return s.Name, addr - s.Value, true
+			}
+		}
+		if sect != nil {
+			return sect.Name, addr - sect.Address, true
+		}
+		return "", 0, false
+	}
+}
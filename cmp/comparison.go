@@ -1,11 +1,14 @@
 package cmp
 
 import (
+	"errors"
 	"os"
 	"regexp"
 
+	"github.com/tzneal/bincmp/ar"
+	"github.com/tzneal/bincmp/inline"
+	"github.com/tzneal/bincmp/lines"
 	"github.com/tzneal/bincmp/nm"
-	"github.com/tzneal/bincmp/objdump"
 	"github.com/tzneal/bincmp/readelf"
 )
 
@@ -21,6 +24,36 @@ type Options struct {
 	Pattern     string
 	Writer      Writer
 	Disassemble bool
+	// Arch selects the slice to compare in Mach-O universal binaries
+	Arch string
+	// Kinds restricts the symbol report to symbols of these kinds, all
+	// symbols are reported if it is empty
+	Kinds []nm.SymbolKind
+	// Demangle shows C++ and Rust symbols by their demangled names
+	Demangle bool
+	// Changed also reports symbols and sections whose contents changed
+	// while their size stayed the same
+	Changed bool
+}
+
+// modified reports whether matched symbols or sections of the same size
+// should be reported, given the hashes of their contents.
+func (o Options) modified(hashA, hashB string) bool {
+	return o.Changed && hashA != "" && hashB != "" && hashA != hashB
+}
+
+// wantKind reports whether a pair of matched symbols passes the Kinds
+// filter. A symbol missing from one binary only has the kind of the other.
+func (o Options) wantKind(symA, symB nm.Symbol) bool {
+	if len(o.Kinds) == 0 {
+		return true
+	}
+	for _, k := range o.Kinds {
+		if (!symA.IsEmpty() && symA.Kind == k) || (!symB.IsEmpty() && symB.Kind == k) {
+			return true
+		}
+	}
+	return false
 }
 
 // NewComparer creates a comparer used to compare between binaries
@@ -44,43 +77,175 @@ func (c *Comparer) CompareFiles() error {
 	}
 	return c.w.StartFiles(aInf, bInf)
 }
+
+// CompareBuildInfo reports the differences between the build info embedded
+// in two Go binaries: the Go version, the main module, the build settings
+// and the version and checksum of each dependency. Nothing is reported if
+// neither file is a Go binary.
+func (c *Comparer) CompareBuildInfo() error {
+	aKnown, bKnown, keys := uniqSettingKeys(listBuildSettings(c.fileA), listBuildSettings(c.fileB))
+
+	first := true
+	for _, key := range keys {
+		if aKnown[key] == bKnown[key] {
+			continue
+		}
+		if first {
+			first = false
+			c.w.StartBuildInfo()
+			defer c.w.EndBuildInfo()
+		}
+		if err := c.w.WriteBuildSetting(aKnown[key], bKnown[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CompareMembers reports the size changes of the members of two static
+// archives, matched by name. Nothing is reported for other files.
+func (c *Comparer) CompareMembers() error {
+	aMems, err := listMembers(c.fileA)
+	if err != nil {
+		return err
+	}
+	bMems, err := listMembers(c.fileB)
+	if err != nil {
+		return err
+	}
+	if aMems == nil || bMems == nil {
+		return nil
+	}
+
+	aKnown, bKnown, names := uniqMemberNames(aMems, bMems)
+
+	re := regexp.MustCompile(c.o.Pattern)
+	first := true
+	for _, name := range names {
+		if !re.MatchString(name) {
+			continue
+		}
+		if aKnown[name].Size == bKnown[name].Size {
+			continue
+		}
+		if first {
+			first = false
+			c.w.StartMembers()
+			defer c.w.EndMembers()
+		}
+		if err := c.w.WriteMember(aKnown[name], bKnown[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listMembers returns the members of an archive, or nil if the file isn't
+// one.
+func listMembers(filename string) ([]ar.Member, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if !ar.IsArchive(f) {
+		return nil, nil
+	}
+	return ar.List(f)
+}
+
+// CompareSymbols reports the symbols whose size changed, that were added or
+// that were removed, and with the Changed option those whose contents
+// changed. A removed symbol whose contents match those of an added one is
+// reported as renamed, paired with the added symbol. With the Changed
+// option, removed functions are also paired with added ones that have the
+// same disassembly.
 func (c *Comparer) CompareSymbols() error {
-	aSyms, err := nm.ListSymbols(c.fileA)
+	aSyms, err := nm.ListSymbolsArch(c.fileA, c.o.Arch)
 	if err != nil {
 		return err
 	}
-	bSyms, err := nm.ListSymbols(c.fileB)
+	bSyms, err := nm.ListSymbolsArch(c.fileB, c.o.Arch)
 	if err != nil {
 		return err
 	}
+	if c.o.Demangle {
+		if err := nm.Demangle(aSyms); err != nil {
+			return err
+		}
+		if err := nm.Demangle(bSyms); err != nil {
+			return err
+		}
+	}
+	// without the Changed option, hashes are only needed to pair removed
+	// symbols with added ones
+	if c.o.Changed || renameCandidates(aSyms, bSyms) {
+		if err := nm.HashContents(c.fileA, c.o.Arch, aSyms); err != nil {
+			return err
+		}
+		if err := nm.HashContents(c.fileB, c.o.Arch, bSyms); err != nil {
+			return err
+		}
+	}
 
+	code := newCodeComparer(c.fileA, c.fileB, c.o.Arch, aSyms, bSyms)
 	aKnown, bKnown, symNames := uniqSymNames(aSyms, bSyms)
+	// disassembling both binaries is slow and needs the go tool, so
+	// functions are only paired by their code with the Changed option
+	var sameCode func(symA, symB nm.Symbol) bool
+	if c.o.Changed {
+		sameCode = code.sameCode
+	}
+	renames := matchRenames(aKnown, bKnown, symNames, sameCode)
+	renamed := make(map[string]bool, len(renames))
+	for _, bn := range renames {
+		renamed[bn] = true
+	}
 
 	first := true
 	re := regexp.MustCompile(c.o.Pattern)
+	match := func(sym nm.Symbol) bool {
+		return !sym.IsEmpty() && (re.MatchString(symKey(sym)) || (sym.Demangled != "" && re.MatchString(sym.Demangled)))
+	}
 	for _, name := range symNames {
-		if !re.MatchString(name) {
+		if renamed[name] {
+			// reported with the name it had in A
 			continue
 		}
-		if aKnown[name].Size == bKnown[name].Size {
+		symA, symB := aKnown[name], bKnown[name]
+		if bn, ok := renames[name]; ok {
+			symB = bKnown[bn]
+		} else if symA.Size == symB.Size && !c.o.modified(symA.Hash, symB.Hash) {
+			continue
+		}
+		if !match(symA) && !match(symB) {
+			continue
+		}
+		if !c.o.wantKind(symA, symB) {
 			continue
 		}
+		// functions whose bytes changed only because the code or data they
+		// refer to moved aren't modified
+		if symA.Size == symB.Size && symA.Hash != symB.Hash && code.sameCode(symA, symB) {
+			if symKey(symA) == symKey(symB) {
+				continue
+			}
+			// still shown as renamed
+			symB.Hash = symA.Hash
+		}
 
 		if first {
 			c.w.StartSymbols()
 			defer c.w.EndSymbols()
 			first = false
 		}
-		if err := c.w.WriteSymbol(aKnown[name], bKnown[name]); err != nil {
+		if err := c.w.WriteSymbol(symA, symB); err != nil {
 			return err
 		}
-		if c.o.Disassemble {
-			fnA, err := objdump.DisassembleFunction(c.fileA, name)
-			if err != nil && !objdump.IsNotFound(err) {
-				return err
-			}
-			fnB, err := objdump.DisassembleFunction(c.fileB, name)
-			if err != nil && !objdump.IsNotFound(err) {
+		// objdump can't pick functions out of archive members
+		if c.o.Disassemble && symA.Member == "" && symB.Member == "" {
+			fnA, fnB, err := code.functions(symA.Name, symB.Name)
+			if err != nil {
 				return err
 			}
 			// both are empty if it's not a function, one may be empty if
@@ -94,15 +259,284 @@ func (c *Comparer) CompareSymbols() error {
 	return nil
 }
 
+// CompareGroups reports the size changes of groups of symbols, e.g. of
+// each C++ namespace with the "namespace" grouping or each Go module with
+// the "module" grouping. The pattern and kinds options select the symbols
+// that are counted.
+func (c *Comparer) CompareGroups(by string) error {
+	aKnown, bKnown, err := c.listGroups(by)
+	if err != nil {
+		return err
+	}
+
+	first := true
+	for _, name := range groupNames(aKnown, bKnown) {
+		if aKnown[name].Size == bKnown[name].Size && aKnown[name].Version == bKnown[name].Version {
+			continue
+		}
+		if first {
+			first = false
+			c.w.StartGroups(by)
+			defer c.w.EndGroups()
+		}
+		if err := c.w.WriteGroup(aKnown[name], bKnown[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CompareTree reports the size changes of the groups of CompareGroups as a
+// tree of their slash separated path segments, e.g. of Go packages with
+// golang.org/x/net/http2 below golang.org, x and net, each showing the
+// total of the groups below it. Levels below depth are collapsed into
+// their parent unless depth is 0, and nodes that changed by less than
+// minDelta bytes are left out unless a node below them is shown.
+func (c *Comparer) CompareTree(by string, depth int, minDelta int64) error {
+	aKnown, bKnown, err := c.listGroups(by)
+	if err != nil {
+		return err
+	}
+	rows := newTree(aKnown, bKnown).rows(0, depth, minDelta)
+	if len(rows) == 0 {
+		return nil
+	}
+	c.w.StartTree(by)
+	defer c.w.EndTree()
+	for _, r := range rows {
+		if err := c.w.WriteTreeNode(r.level, r.a, r.b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listGroups lists and groups the symbols of both binaries for
+// CompareGroups and CompareTree, or their source lines for the groupings
+// by source position.
+func (c *Comparer) listGroups(by string) (map[string]Group, map[string]Group, error) {
+	if err := ValidGrouping(by); err != nil {
+		return nil, nil, err
+	}
+	if group, ok := sourceGroupers[by]; ok {
+		return c.listSourceGroups(group)
+	}
+	aSyms, err := nm.ListSymbolsArch(c.fileA, c.o.Arch)
+	if err != nil {
+		return nil, nil, err
+	}
+	bSyms, err := nm.ListSymbolsArch(c.fileB, c.o.Arch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := nm.Demangle(aSyms); err != nil {
+		return nil, nil, err
+	}
+	if err := nm.Demangle(bSyms); err != nil {
+		return nil, nil, err
+	}
+
+	re := regexp.MustCompile(c.o.Pattern)
+	want := func(syms []nm.Symbol) []nm.Symbol {
+		var ret []nm.Symbol
+		for _, s := range syms {
+			if (re.MatchString(s.Name) || (s.Demangled != "" && re.MatchString(s.Demangled))) &&
+				c.o.wantKind(s, nm.Symbol{}) {
+				ret = append(ret, s)
+			}
+		}
+		return ret
+	}
+	groupA, err := groupers[by](c.fileA)
+	if err != nil {
+		return nil, nil, err
+	}
+	groupB, err := groupers[by](c.fileB)
+	if err != nil {
+		return nil, nil, err
+	}
+	return groupSymbols(want(aSyms), groupA), groupSymbols(want(bSyms), groupB), nil
+}
+
+// listSourceGroups groups the source lines of both binaries whose files
+// match the pattern.
+func (c *Comparer) listSourceGroups(group func(lines.Line) string) (map[string]Group, map[string]Group, error) {
+	aLines, err := lines.ListLines(c.fileA, c.o.Arch)
+	if err != nil {
+		return nil, nil, err
+	}
+	bLines, err := lines.ListLines(c.fileB, c.o.Arch)
+	if err != nil {
+		return nil, nil, err
+	}
+	re := regexp.MustCompile(c.o.Pattern)
+	want := func(ls []lines.Line) []lines.Line {
+		var ret []lines.Line
+		for _, l := range ls {
+			if re.MatchString(l.File) {
+				ret = append(ret, l)
+			}
+		}
+		return ret
+	}
+	return groupLines(want(aLines), group), groupLines(want(bLines), group), nil
+}
+
+// CompareStrings reports the string literals of two Go binaries that one
+// has and the other doesn't, the largest first, like a help text or a
+// generated table added as a string constant. Strings whose bytes are
+// still there, only split differently, and the names and contents of files
+// embedded into embed.FS variables, which the linker stores with the
+// strings, aren't reported.
+func (c *Comparer) CompareStrings() error {
+	aStrs, err := nm.ListStrings(c.fileA, c.o.Arch)
+	if err != nil {
+		return err
+	}
+	bStrs, err := nm.ListStrings(c.fileB, c.o.Arch)
+	if err != nil {
+		return err
+	}
+
+	// embedded files are reported by CompareEmbeds
+	aEmbeds, err := nm.ListEmbeds(c.fileA, c.o.Arch)
+	if err != nil {
+		return err
+	}
+	bEmbeds, err := nm.ListEmbeds(c.fileB, c.o.Arch)
+	if err != nil {
+		return err
+	}
+	aStrs = withoutEmbeds(aStrs, aEmbeds)
+	bStrs = withoutEmbeds(bStrs, bEmbeds)
+
+	aKnown, bKnown, values := uniqStrings(aStrs, bStrs)
+	resplit := resplitStrings(aKnown, bKnown)
+
+	re := regexp.MustCompile(c.o.Pattern)
+	first := true
+	for _, v := range values {
+		if !re.MatchString(v) {
+			continue
+		}
+		_, inA := aKnown[v]
+		_, inB := bKnown[v]
+		if inA && inB || resplit[v] {
+			continue
+		}
+		if first {
+			first = false
+			c.w.StartStrings()
+			defer c.w.EndStrings()
+		}
+		if err := c.w.WriteString(aKnown[v], bKnown[v]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CompareEmbeds reports the files and directories embedded into embed.FS
+// variables of two Go binaries that were added, removed or changed size,
+// and with the Changed option the files whose contents changed.
+func (c *Comparer) CompareEmbeds() error {
+	aEmbeds, err := nm.ListEmbeds(c.fileA, c.o.Arch)
+	if err != nil {
+		return err
+	}
+	bEmbeds, err := nm.ListEmbeds(c.fileB, c.o.Arch)
+	if err != nil {
+		return err
+	}
+
+	aKnown, bKnown, names := uniqEmbedNames(aEmbeds, bEmbeds)
+
+	re := regexp.MustCompile(c.o.Pattern)
+	first := true
+	for _, name := range names {
+		if !re.MatchString(name) {
+			continue
+		}
+		embA, inA := aKnown[name]
+		embB, inB := bKnown[name]
+		if inA && inB && embA.Size == embB.Size && !c.o.modified(embA.Hash, embB.Hash) {
+			continue
+		}
+		if first {
+			first = false
+			c.w.StartEmbeds()
+			defer c.w.EndEmbeds()
+		}
+		if err := c.w.WriteEmbed(embA, embB); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CompareInlining reports, for each function in both binaries, the calls
+// the compiler started or stopped inlining into it and the size of the code
+// they account for, read from the DWARF info. Functions only in one binary
+// aren't reported, as all of their calls would be.
+func (c *Comparer) CompareInlining() error {
+	aFuncs, err := inline.ListFunctions(c.fileA, c.o.Arch)
+	if err != nil {
+		return err
+	}
+	bFuncs, err := inline.ListFunctions(c.fileB, c.o.Arch)
+	if err != nil {
+		return err
+	}
+	bKnown := make(map[string]inline.Function, len(bFuncs))
+	for _, fn := range bFuncs {
+		bKnown[fn.Name] = fn
+	}
+
+	re := regexp.MustCompile(c.o.Pattern)
+	first := true
+	for _, fnA := range aFuncs {
+		fnB, ok := bKnown[fnA.Name]
+		if !ok {
+			continue
+		}
+		aCalls, bCalls, callees := uniqCalleeNames(fnA, fnB)
+		for _, callee := range callees {
+			if !re.MatchString(fnA.Name) && !re.MatchString(callee) {
+				continue
+			}
+			if !aCalls[callee].IsEmpty() && !bCalls[callee].IsEmpty() {
+				continue
+			}
+			if first {
+				first = false
+				c.w.StartInlining()
+				defer c.w.EndInlining()
+			}
+			if err := c.w.WriteInlined(fnA.Name, aCalls[callee], bCalls[callee]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (c *Comparer) CompareSections() error {
-	aSects, err := readelf.ListSections(c.fileA)
+	aSects, err := readelf.ListSectionsArch(c.fileA, c.o.Arch)
 	if err != nil {
 		return err
 	}
-	bSects, err := readelf.ListSections(c.fileB)
+	bSects, err := readelf.ListSectionsArch(c.fileB, c.o.Arch)
 	if err != nil {
 		return err
 	}
+	if c.o.Changed {
+		if err := readelf.HashContents(c.fileA, c.o.Arch, aSects); err != nil {
+			return err
+		}
+		if err := readelf.HashContents(c.fileB, c.o.Arch, bSects); err != nil {
+			return err
+		}
+	}
 
 	aKnown, bKnown, sectNames := uniqSectNames(aSects, bSects)
 
@@ -113,7 +547,7 @@ func (c *Comparer) CompareSections() error {
 			continue
 		}
 
-		if aKnown[name].Size == bKnown[name].Size {
+		if aKnown[name].Size == bKnown[name].Size && !c.o.modified(aKnown[name].Hash, bKnown[name].Hash) {
 			continue
 		}
 		if first {
@@ -128,3 +562,120 @@ func (c *Comparer) CompareSections() error {
 
 	return nil
 }
+
+// CompareImports reports the libraries and symbols that one binary imports
+// and the other doesn't.
+func (c *Comparer) CompareImports() error {
+	aImps, err := nm.ListImports(c.fileA, c.o.Arch)
+	if err != nil {
+		return err
+	}
+	bImps, err := nm.ListImports(c.fileB, c.o.Arch)
+	if err != nil {
+		return err
+	}
+
+	aKnown, bKnown, imps := uniqImports(aImps, bImps)
+
+	re := regexp.MustCompile(c.o.Pattern)
+	first := true
+	for _, imp := range imps {
+		if !re.MatchString(imp.Name) && !re.MatchString(imp.Library) {
+			continue
+		}
+		_, inA := aKnown[imp]
+		_, inB := bKnown[imp]
+		if inA && inB {
+			continue
+		}
+		if first {
+			first = false
+			c.w.StartImports()
+			defer c.w.EndImports()
+		}
+		if err := c.w.WriteImport(aKnown[imp], bKnown[imp]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CompareVersions reports the minimum version of each versioned library
+// interface, such as GLIBC or GLIBCXX, the two binaries need. Unlike the
+// other reports, it lists unchanged interfaces too.
+func (c *Comparer) CompareVersions() error {
+	aImps, err := nm.ListImports(c.fileA, c.o.Arch)
+	if err != nil {
+		return err
+	}
+	bImps, err := nm.ListImports(c.fileB, c.o.Arch)
+	if err != nil {
+		return err
+	}
+
+	aKnown, bKnown, names := uniqVersionNames(nm.MinimumVersions(aImps), nm.MinimumVersions(bImps))
+
+	re := regexp.MustCompile(c.o.Pattern)
+	first := true
+	for _, name := range names {
+		if !re.MatchString(name) {
+			continue
+		}
+		if first {
+			first = false
+			c.w.StartVersions()
+			defer c.w.EndVersions()
+		}
+		if err := c.w.WriteVersion(aKnown[name], bKnown[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrExportsRemoved is returned by CompareExports after reporting exports
+// that are missing from the second binary.
+var ErrExportsRemoved = errors.New("exports were removed")
+
+// CompareExports reports exports that were added, removed or changed their
+// kind, version, binding, visibility or data size. If any were removed, it
+// returns ErrExportsRemoved.
+func (c *Comparer) CompareExports() error {
+	aExps, err := nm.ListExports(c.fileA, c.o.Arch)
+	if err != nil {
+		return err
+	}
+	bExps, err := nm.ListExports(c.fileB, c.o.Arch)
+	if err != nil {
+		return err
+	}
+
+	aKnown, bKnown, names := uniqExportNames(aExps, bExps)
+
+	re := regexp.MustCompile(c.o.Pattern)
+	first := true
+	removed := false
+	for _, name := range names {
+		if !re.MatchString(name) {
+			continue
+		}
+		if aKnown[name] == bKnown[name] {
+			continue
+		}
+		if first {
+			first = false
+			c.w.StartExports()
+			defer c.w.EndExports()
+		}
+		if err := c.w.WriteExport(aKnown[name], bKnown[name]); err != nil {
+			return err
+		}
+		if bKnown[name].IsEmpty() {
+			removed = true
+		}
+	}
+	if removed {
+		return ErrExportsRemoved
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package cmp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tzneal/bincmp/goname"
+	"github.com/tzneal/bincmp/lines"
+	"github.com/tzneal/bincmp/nm"
+)
+
+// Group is the total size of the symbols of a binary that belong together,
+// such as the symbols of a C++ namespace. Version is set for groupings with
+// versions, like Go modules.
+type Group struct {
+	Name    string
+	Version string
+	Size    int64
+	Symbols int
+}
+
+func (g Group) IsEmpty() bool {
+	return g.Symbols == 0
+}
+
+// groupFunc returns the group of a symbol and the version of the group,
+// if groups have one, or "" for symbols that don't belong to any group.
+type groupFunc func(nm.Symbol) (name, version string)
+
+// groupers map the groupings CompareGroups knows to a function returning
+// the groupFunc for the symbols of a binary.
+var groupers = map[string]func(file string) (groupFunc, error){
+	"module":    moduleGroups,
+	"namespace": unversioned(namespaceGroup),
+	"package":   unversioned(packageGroup),
+	"type":      unversioned(typeGroup),
+}
+
+// sourceGroupers map the groupings CompareGroups knows that attribute the
+// code of a binary by its source lines, rather than its symbols, to the
+// group of a line.
+var sourceGroupers = map[string]func(lines.Line) string{
+	"file": func(l lines.Line) string { return l.File },
+	"line": func(l lines.Line) string { return fmt.Sprintf("%s:%d", l.File, l.Line) },
+}
+
+// unversioned returns a groupFunc for groupings that don't depend on the
+// binary and whose groups have no version.
+func unversioned(group func(nm.Symbol) string) func(string) (groupFunc, error) {
+	return func(string) (groupFunc, error) {
+		return func(s nm.Symbol) (string, string) {
+			return group(s), ""
+		}, nil
+	}
+}
+
+// Groupings returns the groupings CompareGroups knows, sorted by name.
+func Groupings() []string {
+	ret := make([]string, 0, len(groupers)+len(sourceGroupers))
+	for by := range groupers {
+		ret = append(ret, by)
+	}
+	for by := range sourceGroupers {
+		ret = append(ret, by)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// ValidGrouping returns an error if CompareGroups doesn't know the grouping
+// by.
+func ValidGrouping(by string) error {
+	_, ok := groupers[by]
+	if _, src := sourceGroupers[by]; !ok && !src {
+		return fmt.Errorf("unknown grouping %q, expected one of %s", by, strings.Join(Groupings(), ", "))
+	}
+	return nil
+}
+
+// namespaceGroup groups C++ symbols by their outermost namespace and Rust
+// symbols by their crate. Other symbols aren't grouped.
+func namespaceGroup(s nm.Symbol) string {
+	if s.Demangled == "" {
+		return ""
+	}
+	if ns := nm.Namespace(s.Demangled); ns != "" {
+		return ns
+	}
+	return "(global)"
+}
+
+// packageGroup groups Go symbols by import path. Methods, closures,
+// dictionaries, type descriptors and itabs count towards the package
+// defining the function or type. Descriptors and itabs of unnamed types
+// count towards the package of the first named type they are built from,
+// e.g. net/http for "type:map[string]net/http.Header", or for itabs that of
+// the interface, and go to "(types)" if they are only built from predeclared
+// types, like "type:map[string]int". Functions and variables of the runtime
+// count towards "runtime" like any other package, but C symbols, string
+// data and linker generated symbols like go:buildid aren't grouped.
+func packageGroup(s nm.Symbol) string {
+	n := goname.Parse(s.Name)
+	switch n.Kind {
+	case goname.KindOther, goname.KindString:
+		return ""
+	case goname.KindType, goname.KindTypeEq, goname.KindTypeHash, goname.KindTypeData:
+		if n.Package == "" {
+			if pkg := typePackage(n.Func); pkg != "" {
+				return pkg
+			}
+			return "(types)"
+		}
+	case goname.KindItab:
+		if n.Package == "" {
+			if pkg := typePackage(n.Func); pkg != "" {
+				return pkg
+			}
+			if pkg := typePackage(n.Interface); pkg != "" {
+				return pkg
+			}
+			return "(types)"
+		}
+	}
+	if n.Old && n.Package == "" {
+		// object files name their own package ""
+		return `""`
+	}
+	return n.Package
+}
+
+// typePackage returns the package of the first named type in the type
+// expression t, like "main" for "map[string]main.T" or "func(int) main.T",
+// or "" if t only uses predeclared types. Struct tags are skipped.
+func typePackage(t string) string {
+	for len(t) > 0 {
+		if t[0] == '"' {
+			// a struct tag
+			if q, err := strconv.QuotedPrefix(t); err == nil {
+				t = t[len(q):]
+				continue
+			}
+		}
+		i := strings.IndexAny(t, "[]*(){},;<\" \t")
+		if i == 0 || t[0] == '.' {
+			// a separator, or the dots of a variadic parameter
+			t = t[1:]
+			continue
+		}
+		if i < 0 {
+			i = len(t)
+		}
+		if n := goname.Parse("type:" + t[:i]); n.Package != "" {
+			return n.Package
+		}
+		t = t[i:]
+	}
+	return ""
+}
+
+// typeGroup groups Go symbols by the named type they belong to: its type
+// descriptor and that of its pointer type, its equality and hash functions,
+// the itabs for the interfaces it satisfies, and its methods with their
+// wrappers and closures. Instantiations of a generic type count towards the
+// generic type. Symbols that don't belong to a named type aren't grouped.
+func typeGroup(s nm.Symbol) string {
+	n := goname.Parse(s.Name)
+	if n.Kind == goname.KindOther || n.Receiver == "" {
+		return ""
+	}
+	pkg := n.Package
+	if n.Old && pkg == "" {
+		// object files name their own package ""
+		pkg = `""`
+	}
+	return pkg + "." + n.Receiver
+}
+
+// groupLines sums the sizes of the code of the lines of each group.
+func groupLines(ls []lines.Line, group func(lines.Line) string) map[string]Group {
+	ret := map[string]Group{}
+	for _, l := range ls {
+		name := group(l)
+		g := ret[name]
+		g.Name = name
+		g.Size += l.Size
+		g.Symbols++
+		ret[name] = g
+	}
+	return ret
+}
+
+// groupSymbols sums the sizes of the symbols of each group.
+func groupSymbols(syms []nm.Symbol, group groupFunc) map[string]Group {
+	ret := map[string]Group{}
+	for _, s := range syms {
+		name, version := group(s)
+		if name == "" {
+			continue
+		}
+		g := ret[name]
+		g.Name = name
+		g.Version = version
+		g.Size += s.Size
+		g.Symbols++
+		ret[name] = g
+	}
+	return ret
+}
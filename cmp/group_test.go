@@ -0,0 +1,127 @@
+package cmp
+
+import (
+	"testing"
+
+	"github.com/tzneal/bincmp/nm"
+)
+
+func TestPackageGroup(t *testing.T) {
+	for _, tc := range []struct {
+		sym string
+		exp string
+	}{
+		// functions, methods and closures
+		{"main.main", "main"},
+		{"runtime.mallocgc", "runtime"},
+		{"runtime.memmove.abi0", "runtime"},
+		{"net/http.(*Server).Serve", "net/http"},
+		{"net/http.(*Server).Serve.func1", "net/http"},
+		{"net/http.(*Server).Serve-fm", "net/http"},
+		{"net/http.Header.Get", "net/http"},
+		{"gopkg.in/yaml%2ev3.Unmarshal", "gopkg.in/yaml.v3"},
+		{"vendor/golang.org/x/net/http2/hpack.(*Decoder).Write", "vendor/golang.org/x/net/http2/hpack"},
+		{"main.(*List[go.shape.int]).Push", "main"},
+		{"main..dict.Map[int,string]", "main"},
+		{"time..inittask", "time"},
+
+		// type descriptors and algorithms of named types
+		{"type:net/http.Server", "net/http"},
+		{"type:*net/http.Server", "net/http"},
+		{"type:.eq.main.T", "main"},
+		{"type:.hash.main.T", "main"},
+		{"go:itab.*os.File,io.Writer", "os"},
+
+		// unnamed types count towards the first named type they are built
+		// from, if it has a package
+		{"type:[]net/http.Header", "net/http"},
+		{"type:*[]net/http.Header", "net/http"},
+		{"type:[4]main.T", "main"},
+		{"type:.eq.[2]main.T", "main"},
+		{"type:map[string]net/http.Header", "net/http"},
+		{"type:map[main.K][]os.File", "main"},
+		{"type:func(int, ...main.T) error", "main"},
+		{"type:func() (int, *os.File)", "os"},
+		{"type:chan<- main.T", "main"},
+		{"type:<-chan []main.T", "main"},
+		{`type:struct { F int "json:\"a.b\""; G main.T }`, "main"},
+		{"type:[]main.List[int]", "main"},
+		{"type:noalg.map.bucket[string]main.T", "main"},
+		{"type:map[string]int", "(types)"},
+		{"type:[]string", "(types)"},
+		{"type:int", "(types)"},
+		{"type:func(int) error", "(types)"},
+		{"type:.eq.[2]interface {}", "(types)"},
+		{"type:noalg.map.bucket[string]int", "(types)"},
+		{"type:[]go.shape.int", "(types)"},
+
+		// itabs of unnamed types count towards their type, else their
+		// interface
+		{"go:itab.[]main.T,sort.Interface", "main"},
+		{"go:itab.func(),main.Runner", "main"},
+		{"go:itab.*[]int,sort.Interface", "sort"},
+		{"go:itab.map[string]int,interface { Len() int }", "(types)"},
+
+		// object files name their own package ""
+		{`"".main`, `""`},
+		{`"".(*T).M`, `""`},
+		{"type..eq.main.T", "main"},
+
+		// not grouped
+		{"go:string.*", ""},
+		{`go.string."hello"`, ""},
+		{"go:buildid", ""},
+		{"gclocals·d4dc2f11db048877dbc0f60a22b4adb3", ""},
+		{"_cgo_init", ""},
+		{"_rt0_amd64_linux", ""},
+		{"memcpy@GLIBC_2.14", ""},
+	} {
+		if got := packageGroup(nm.Symbol{Name: tc.sym}); got != tc.exp {
+			t.Errorf("%s: expected %q, got %q", tc.sym, tc.exp, got)
+		}
+	}
+}
+
+func TestTypeGroup(t *testing.T) {
+	for _, tc := range []struct {
+		sym string
+		exp string
+	}{
+		{"type:example.com/m.T", "example.com/m.T"},
+		{"type:*example.com/m.T", "example.com/m.T"},
+		{"type:.eq.example.com/m.T", "example.com/m.T"},
+		{"type:.hash.example.com/m.T", "example.com/m.T"},
+		{"go:itab.*example.com/m.T,io.Writer", "example.com/m.T"},
+		{"go:itab.example.com/m.T,fmt.Stringer", "example.com/m.T"},
+		{"example.com/m.T.String", "example.com/m.T"},
+		{"example.com/m.(*T).Write", "example.com/m.T"},
+		{"example.com/m.(*T).Write-fm", "example.com/m.T"},
+		{"example.com/m.(*T).Write.func1", "example.com/m.T"},
+		{"example.com/m.(*T).Write.func1.1", "example.com/m.T"},
+		{"example.com/m.T.String.abi0", "example.com/m.T"},
+
+		// instantiations count towards the generic type
+		{"type:example.com/m.List[int]", "example.com/m.List"},
+		{"type:*example.com/m.List[string]", "example.com/m.List"},
+		{"go:itab.*example.com/m.List[int],fmt.Stringer", "example.com/m.List"},
+		{"example.com/m.(*List[go.shape.int]).Push", "example.com/m.List"},
+		{"example.com/m.List[go.shape.string].Len", "example.com/m.List"},
+		{"example.com/m.(*List[go.shape.int]).Push-fm", "example.com/m.List"},
+
+		// object files name their own package ""
+		{`"".(*T).M`, `"".T`},
+		{"type..eq.main.T", "main.T"},
+
+		// not grouped
+		{"example.com/m.New", ""},
+		{"example.com/m.New.func1", ""},
+		{"type:map[string]int", ""},
+		{"type:[]example.com/m.T", ""},
+		{"go:string.*", ""},
+		{"_cgo_init", ""},
+	} {
+		if got := typeGroup(nm.Symbol{Name: tc.sym}); got != tc.exp {
+			t.Errorf("%s: expected %q, got %q", tc.sym, tc.exp, got)
+		}
+	}
+}
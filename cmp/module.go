@@ -0,0 +1,83 @@
+package cmp
+
+import (
+	"debug/buildinfo"
+	"runtime/debug"
+	"strings"
+
+	"github.com/tzneal/bincmp/nm"
+)
+
+// moduleGroups groups the symbols of a Go binary by the module providing
+// their package, according to the build info embedded in the binary. A
+// package belongs to the module with the longest path that is a prefix of
+// its import path. Standard library packages are grouped as "std", with the
+// Go version as their version.
+func moduleGroups(file string) (groupFunc, error) {
+	bi, err := buildinfo.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return moduleGroup(bi), nil
+}
+
+// moduleGroup returns the groupFunc of moduleGroups for the build info bi.
+func moduleGroup(bi *debug.BuildInfo) groupFunc {
+	versions := map[string]string{}
+	versions[bi.Main.Path] = moduleVersion(&bi.Main)
+	for _, d := range bi.Deps {
+		versions[d.Path] = moduleVersion(d)
+	}
+
+	return func(s nm.Symbol) (string, string) {
+		pkg := packageGroup(s)
+		switch pkg {
+		case "":
+			return "", ""
+		case "main":
+			return bi.Main.Path, versions[bi.Main.Path]
+		case "(types)":
+			return pkg, ""
+		}
+		for mod := pkg; ; {
+			if v, ok := versions[mod]; ok {
+				return mod, v
+			}
+			i := strings.LastIndexByte(mod, '/')
+			if i < 0 {
+				break
+			}
+			mod = mod[:i]
+		}
+		if isStd(pkg) {
+			return "std", bi.GoVersion
+		}
+		return "(unknown)", ""
+	}
+}
+
+// moduleVersion returns the version of a module, or the path and version
+// of the module replacing it.
+func moduleVersion(m *debug.Module) string {
+	r := m.Replace
+	switch {
+	case r == nil:
+		return m.Version
+	case r.Version == "" || r.Version == "(devel)":
+		// replaced by a directory
+		return r.Path
+	case r.Path == m.Path:
+		return r.Version
+	}
+	return r.Path + "@" + r.Version
+}
+
+// isStd reports whether an import path is part of the standard library,
+// whose first path element, unlike that of module paths, has no dot.
+func isStd(pkg string) bool {
+	first := pkg
+	if i := strings.IndexByte(pkg, '/'); i >= 0 {
+		first = pkg[:i]
+	}
+	return !strings.Contains(first, ".")
+}
@@ -0,0 +1,68 @@
+package cmp
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/tzneal/bincmp/nm"
+)
+
+func TestModuleGroup(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.22.1",
+		Main:      debug.Module{Path: "example.com/app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "golang.org/x/net", Version: "v0.20.0"},
+			{Path: "golang.org/x/net/http2", Version: "v0.1.0"},
+			{Path: "gopkg.in/yaml.v3", Version: "v3.0.1"},
+			{Path: "github.com/old/lib", Version: "v1.0.0", Replace: &debug.Module{Path: "github.com/new/lib", Version: "v1.1.0"}},
+		},
+	}
+	group := moduleGroup(bi)
+	for _, tc := range []struct {
+		sym     string
+		mod     string
+		version string
+	}{
+		{"main.main", "example.com/app", "(devel)"},
+		{"example.com/app/internal/db.Open", "example.com/app", "(devel)"},
+		{"golang.org/x/net/html.Parse", "golang.org/x/net", "v0.20.0"},
+		// the longest module path wins
+		{"golang.org/x/net/http2.(*Framer).WriteData", "golang.org/x/net/http2", "v0.1.0"},
+		{"golang.org/x/net/http2/hpack.NewEncoder", "golang.org/x/net/http2", "v0.1.0"},
+		{"gopkg.in/yaml%2ev3.Unmarshal", "gopkg.in/yaml.v3", "v3.0.1"},
+		{"github.com/old/lib.F", "github.com/old/lib", "github.com/new/lib@v1.1.0"},
+		{"runtime.mallocgc", "std", "go1.22.1"},
+		{"net/http.(*Server).Serve", "std", "go1.22.1"},
+		// the packages the standard library vendors are part of it
+		{"vendor/golang.org/x/net/http2/hpack.(*Decoder).Write", "std", "go1.22.1"},
+		{"type:map[string]int", "(types)", ""},
+		{"github.com/missing/dep.F", "(unknown)", ""},
+		{"_cgo_init", "", ""},
+	} {
+		mod, version := group(nm.Symbol{Name: tc.sym})
+		if mod != tc.mod || version != tc.version {
+			t.Errorf("%s: expected %s %s, got %s %s", tc.sym, tc.mod, tc.version, mod, version)
+		}
+	}
+}
+
+func TestModuleVersion(t *testing.T) {
+	for _, tc := range []struct {
+		mod debug.Module
+		exp string
+	}{
+		{debug.Module{Path: "golang.org/x/net", Version: "v0.20.0"}, "v0.20.0"},
+		// replaced by another version of itself
+		{debug.Module{Path: "golang.org/x/net", Version: "v0.20.0", Replace: &debug.Module{Path: "golang.org/x/net", Version: "v0.19.0"}}, "v0.19.0"},
+		// replaced by a fork
+		{debug.Module{Path: "golang.org/x/net", Version: "v0.20.0", Replace: &debug.Module{Path: "github.com/fork/net", Version: "v0.20.1"}}, "github.com/fork/net@v0.20.1"},
+		// replaced by a directory
+		{debug.Module{Path: "golang.org/x/net", Version: "v0.20.0", Replace: &debug.Module{Path: "../net"}}, "../net"},
+		{debug.Module{Path: "golang.org/x/net", Version: "v0.20.0", Replace: &debug.Module{Path: "/src/net", Version: "(devel)"}}, "/src/net"},
+	} {
+		if got := moduleVersion(&tc.mod); got != tc.exp {
+			t.Errorf("%+v: expected %q, got %q", tc.mod, tc.exp, got)
+		}
+	}
+}
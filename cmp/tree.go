@@ -0,0 +1,112 @@
+package cmp
+
+import (
+	"sort"
+	"strings"
+)
+
+// treeNode sums the groups of both binaries whose names start with the
+// path of the node.
+type treeNode struct {
+	a, b     Group
+	children map[string]*treeNode
+}
+
+// newTree builds the tree of the slash separated segments of the group
+// names.
+func newTree(aKnown, bKnown map[string]Group) *treeNode {
+	root := &treeNode{}
+	for _, g := range aKnown {
+		root.add(g, func(n *treeNode) *Group { return &n.a })
+	}
+	for _, g := range bKnown {
+		root.add(g, func(n *treeNode) *Group { return &n.b })
+	}
+	return root
+}
+
+// add adds g to the side of each node along its path selected by side.
+func (n *treeNode) add(g Group, side func(*treeNode) *Group) {
+	segs := strings.Split(g.Name, "/")
+	if len(segs) > 1 && segs[0] == "" {
+		// absolute paths start at their first directory
+		segs = append([]string{"/" + segs[1]}, segs[2:]...)
+	}
+	for i := range segs {
+		child := n.children[segs[i]]
+		if child == nil {
+			child = &treeNode{}
+			if n.children == nil {
+				n.children = map[string]*treeNode{}
+			}
+			n.children[segs[i]] = child
+		}
+		n = child
+
+		sum := side(n)
+		sum.Name = strings.Join(segs[:i+1], "/")
+		sum.Size += g.Size
+		sum.Symbols += g.Symbols
+		if i == len(segs)-1 {
+			sum.Version = g.Version
+		}
+	}
+}
+
+// treeRow is a node of the tree as it is reported, at its level below the
+// root.
+type treeRow struct {
+	level int
+	a, b  Group
+}
+
+// rows returns the nodes below n that are shown, depth first.
+func (n *treeNode) rows(level, depth int, minDelta int64) []treeRow {
+	if depth > 0 && level >= depth {
+		return nil
+	}
+	var ret []treeRow
+	for _, child := range n.sortedChildren() {
+		if child.shown(level, depth, minDelta) {
+			ret = append(ret, treeRow{level: level, a: child.a, b: child.b})
+			ret = append(ret, child.rows(level+1, depth, minDelta)...)
+		}
+	}
+	return ret
+}
+
+func (n *treeNode) sortedChildren() []*treeNode {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ret := make([]*treeNode, len(names))
+	for i, name := range names {
+		ret[i] = n.children[name]
+	}
+	return ret
+}
+
+// shown reports whether a node at level is reported: if it changed by at
+// least minDelta bytes, or if any node below it that isn't collapsed by
+// depth is.
+func (n *treeNode) shown(level, depth int, minDelta int64) bool {
+	delta := n.b.Size - n.a.Size
+	if delta < 0 {
+		delta = -delta
+	}
+	changed := delta != 0 || n.a.IsEmpty() != n.b.IsEmpty() || n.a.Version != n.b.Version
+	if changed && delta >= minDelta {
+		return true
+	}
+	if depth > 0 && level+1 >= depth {
+		return false
+	}
+	for _, child := range n.children {
+		if child.shown(level+1, depth, minDelta) {
+			return true
+		}
+	}
+	return false
+}
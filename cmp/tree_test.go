@@ -0,0 +1,122 @@
+package cmp
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func groups(sizes map[string]int64) map[string]Group {
+	ret := make(map[string]Group, len(sizes))
+	for name, size := range sizes {
+		ret[name] = Group{Name: name, Size: size, Symbols: 1}
+	}
+	return ret
+}
+
+// rowNames formats rows as their name indented by their level.
+func rowNames(rows []treeRow) []string {
+	var ret []string
+	for _, r := range rows {
+		name := r.a.Name
+		if r.a.IsEmpty() {
+			name = r.b.Name
+		}
+		ret = append(ret, fmt.Sprintf("%d %s", r.level, name))
+	}
+	return ret
+}
+
+func TestTreeRows(t *testing.T) {
+	a := groups(map[string]int64{
+		"fmt":                    30,
+		"golang.org/x/net/html":  50,
+		"golang.org/x/net/http2": 100,
+		"example.com/m/x":        100,
+		"example.com/m/y":        100,
+	})
+	b := groups(map[string]int64{
+		"fmt":                    30,
+		"golang.org/x/net/html":  50,
+		"golang.org/x/net/http2": 120,
+		"golang.org/x/text":      10,
+		"example.com/m/x":        150,
+		"example.com/m/y":        50,
+	})
+	for _, tc := range []struct {
+		depth    int
+		minDelta int64
+		exp      []string
+	}{
+		{0, 0, []string{
+			// example.com/m didn't change in total, but is kept for the
+			// children that did
+			"0 example.com", "1 example.com/m", "2 example.com/m/x", "2 example.com/m/y",
+			"0 golang.org", "1 golang.org/x", "2 golang.org/x/net", "3 golang.org/x/net/http2", "2 golang.org/x/text",
+		}},
+		{3, 0, []string{
+			"0 example.com", "1 example.com/m", "2 example.com/m/x", "2 example.com/m/y",
+			"0 golang.org", "1 golang.org/x", "2 golang.org/x/net", "2 golang.org/x/text",
+		}},
+		// example.com/m is collapsed, and its changes cancel out
+		{2, 0, []string{"0 golang.org", "1 golang.org/x"}},
+		{1, 0, []string{"0 golang.org"}},
+		{0, 15, []string{
+			"0 example.com", "1 example.com/m", "2 example.com/m/x", "2 example.com/m/y",
+			"0 golang.org", "1 golang.org/x", "2 golang.org/x/net", "3 golang.org/x/net/http2",
+		}},
+		{0, 25, []string{
+			"0 example.com", "1 example.com/m", "2 example.com/m/x", "2 example.com/m/y",
+			"0 golang.org", "1 golang.org/x",
+		}},
+		{2, 40, nil},
+	} {
+		got := rowNames(newTree(a, b).rows(0, tc.depth, tc.minDelta))
+		if !reflect.DeepEqual(got, tc.exp) {
+			t.Errorf("depth %d, min delta %d: expected %v, got %v", tc.depth, tc.minDelta, tc.exp, got)
+		}
+	}
+}
+
+func TestTreeRowsSums(t *testing.T) {
+	a := groups(map[string]int64{"golang.org/x/net/http2": 100, "golang.org/x/net/html": 50})
+	b := groups(map[string]int64{"golang.org/x/net/http2": 120})
+	rows := newTree(a, b).rows(0, 0, 0)
+	if len(rows) != 5 {
+		t.Fatalf("expected 5 rows, got %v", rowNames(rows))
+	}
+	if net := rows[2]; net.a.Size != 150 || net.b.Size != 120 || net.a.Symbols != 2 || net.b.Symbols != 1 {
+		t.Errorf("expected golang.org/x/net to sum its packages, got %+v", net)
+	}
+	// removed groups have no new side
+	if html := rows[3]; html.a.Name != "golang.org/x/net/html" || !html.b.IsEmpty() {
+		t.Errorf("expected golang.org/x/net/html to be removed, got %+v", html)
+	}
+}
+
+func TestTreeRowsVersion(t *testing.T) {
+	a := map[string]Group{"golang.org/x/net": {Name: "golang.org/x/net", Version: "v0.1.0", Size: 10, Symbols: 1}}
+	b := map[string]Group{"golang.org/x/net": {Name: "golang.org/x/net", Version: "v0.2.0", Size: 10, Symbols: 1}}
+	// a new version of the same size is still a change
+	got := rowNames(newTree(a, b).rows(0, 0, 0))
+	exp := []string{"0 golang.org", "1 golang.org/x", "2 golang.org/x/net"}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+}
+
+func TestTreeRowsAbsolute(t *testing.T) {
+	// -by file reports files outside of GOROOT and the module cache by
+	// their absolute path
+	a := groups(map[string]int64{"/tmp/a/main.go": 100, "runtime/proc.go": 500})
+	b := groups(map[string]int64{"/tmp/a/main.go": 110, "runtime/proc.go": 500})
+	got := rowNames(newTree(a, b).rows(0, 0, 0))
+	exp := []string{"0 /tmp", "1 /tmp/a", "2 /tmp/a/main.go"}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+	got = rowNames(newTree(a, b).rows(0, 1, 0))
+	if exp := []string{"0 /tmp"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+}
@@ -2,24 +2,36 @@ package cmp
 
 import (
 	"sort"
+	"strings"
 
+	"github.com/tzneal/bincmp/ar"
+	"github.com/tzneal/bincmp/inline"
 	"github.com/tzneal/bincmp/nm"
 	"github.com/tzneal/bincmp/readelf"
 )
 
 type symMap map[string]nm.Symbol
 
+// symKey identifies a symbol across binaries. Symbols from archives are
+// qualified with their member name, as each member has its own namespace.
+func symKey(s nm.Symbol) string {
+	if s.Member != "" {
+		return s.Member + ":" + s.Name
+	}
+	return s.Name
+}
+
 func uniqSymNames(a, b []nm.Symbol) (symMap, symMap, []string) {
 	names := make(map[string]struct{}, len(a))
 	aKnown := make(map[string]nm.Symbol, len(a))
 	bKnown := make(map[string]nm.Symbol, len(b))
 	for _, an := range a {
-		aKnown[an.Name] = an
-		names[an.Name] = struct{}{}
+		aKnown[symKey(an)] = an
+		names[symKey(an)] = struct{}{}
 	}
 	for _, bn := range b {
-		bKnown[bn.Name] = bn
-		names[bn.Name] = struct{}{}
+		bKnown[symKey(bn)] = bn
+		names[symKey(bn)] = struct{}{}
 	}
 	ret := make([]string, 0, len(names))
 	for n := range names {
@@ -29,6 +41,105 @@ func uniqSymNames(a, b []nm.Symbol) (symMap, symMap, []string) {
 	return aKnown, bKnown, ret
 }
 
+// matchRenames pairs symbols only found in a with symbols of the same kind
+// and contents only found in b, as happens when a symbol is renamed or
+// moved to another package. It returns the names in b by the names in a.
+// Symbols with the same contents are paired in the order of their names.
+// If sameCode isn't nil, functions left over are then paired with those of
+// the same size that it reports to have the same code, as the bytes of
+// functions in linked executables change with the addresses of what they
+// call.
+func matchRenames(a, b symMap, names []string, sameCode func(symA, symB nm.Symbol) bool) map[string]string {
+	added := map[string][]string{}
+	for _, n := range names {
+		if _, ok := a[n]; !ok && b[n].Hash != "" {
+			added[b[n].Hash] = append(added[b[n].Hash], n)
+		}
+	}
+	ret := map[string]string{}
+	for _, n := range names {
+		if _, ok := b[n]; ok || a[n].Hash == "" {
+			continue
+		}
+		candidates := added[a[n].Hash]
+		for i, bn := range candidates {
+			if b[bn].Kind == a[n].Kind {
+				ret[n] = bn
+				added[a[n].Hash] = append(candidates[:i:i], candidates[i+1:]...)
+				break
+			}
+		}
+	}
+	if sameCode == nil {
+		return ret
+	}
+
+	matched := make(map[string]bool, len(ret))
+	for _, bn := range ret {
+		matched[bn] = true
+	}
+	var addedText []string
+	for _, n := range names {
+		if _, ok := a[n]; !ok && !matched[n] && b[n].Kind == nm.KindText && b[n].Size > 0 {
+			addedText = append(addedText, n)
+		}
+	}
+	for _, n := range names {
+		if _, ok := b[n]; ok || ret[n] != "" || a[n].Kind != nm.KindText || a[n].Size <= 0 {
+			continue
+		}
+		for i, bn := range addedText {
+			if b[bn].Size == a[n].Size && sameCode(a[n], b[bn]) {
+				ret[n] = bn
+				addedText = append(addedText[:i:i], addedText[i+1:]...)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// renameCandidates reports whether a has a symbol b doesn't and b has one a
+// doesn't, which matchRenames could pair.
+func renameCandidates(a, b []nm.Symbol) bool {
+	inA := make(map[string]bool, len(a))
+	for _, s := range a {
+		inA[symKey(s)] = true
+	}
+	inB := make(map[string]bool, len(b))
+	added := false
+	for _, s := range b {
+		inB[symKey(s)] = true
+		added = added || !inA[symKey(s)]
+	}
+	if !added {
+		return false
+	}
+	for _, s := range a {
+		if !inB[symKey(s)] {
+			return true
+		}
+	}
+	return false
+}
+
+// groupNames returns the names of the groups of both binaries.
+func groupNames(a, b map[string]Group) []string {
+	names := make(map[string]struct{}, len(a))
+	for n := range a {
+		names[n] = struct{}{}
+	}
+	for n := range b {
+		names[n] = struct{}{}
+	}
+	ret := make([]string, 0, len(names))
+	for n := range names {
+		ret = append(ret, n)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
 type sectMap map[string]readelf.Section
 
 func uniqSectNames(a, b []readelf.Section) (sectMap, sectMap, []string) {
@@ -50,3 +161,325 @@ func uniqSectNames(a, b []readelf.Section) (sectMap, sectMap, []string) {
 	sort.Strings(ret)
 	return aKnown, bKnown, ret
 }
+
+type impMap map[nm.Import]nm.Import
+
+func uniqImports(a, b []nm.Import) (impMap, impMap, []nm.Import) {
+	names := make(map[nm.Import]struct{}, len(a))
+	aKnown := make(impMap, len(a))
+	bKnown := make(impMap, len(b))
+	for _, an := range a {
+		aKnown[an] = an
+		names[an] = struct{}{}
+	}
+	for _, bn := range b {
+		bKnown[bn] = bn
+		names[bn] = struct{}{}
+	}
+	ret := make([]nm.Import, 0, len(names))
+	for n := range names {
+		ret = append(ret, n)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Library != ret[j].Library {
+			return ret[i].Library < ret[j].Library
+		}
+		if ret[i].Name != ret[j].Name {
+			return ret[i].Name < ret[j].Name
+		}
+		return ret[i].Version < ret[j].Version
+	})
+	return aKnown, bKnown, ret
+}
+
+type verMap map[string]nm.Import
+
+// uniqVersionNames matches the minimum versions returned by
+// nm.MinimumVersions by interface name.
+func uniqVersionNames(a, b []nm.Import) (verMap, verMap, []string) {
+	names := make(map[string]struct{}, len(a))
+	aKnown := make(verMap, len(a))
+	bKnown := make(verMap, len(b))
+	for _, an := range a {
+		aKnown[an.Name] = an
+		names[an.Name] = struct{}{}
+	}
+	for _, bn := range b {
+		bKnown[bn.Name] = bn
+		names[bn.Name] = struct{}{}
+	}
+	ret := make([]string, 0, len(names))
+	for n := range names {
+		ret = append(ret, n)
+	}
+	sort.Strings(ret)
+	return aKnown, bKnown, ret
+}
+
+type expMap map[string]nm.Export
+
+// expKey identifies an export. Non-default versions are kept apart, so that
+// foo@@V2 and the foo@V1 kept for compatibility don't collide, while a
+// change of the default version shows as a change of foo.
+func expKey(e nm.Export) string {
+	if e.HiddenVersion {
+		return e.String()
+	}
+	return e.Name
+}
+
+func uniqExportNames(a, b []nm.Export) (expMap, expMap, []string) {
+	names := make(map[string]struct{}, len(a))
+	aKnown := make(expMap, len(a))
+	bKnown := make(expMap, len(b))
+	for _, an := range a {
+		aKnown[expKey(an)] = an
+		names[expKey(an)] = struct{}{}
+	}
+	for _, bn := range b {
+		bKnown[expKey(bn)] = bn
+		names[expKey(bn)] = struct{}{}
+	}
+	ret := make([]string, 0, len(names))
+	for n := range names {
+		ret = append(ret, n)
+	}
+	sort.Strings(ret)
+	return aKnown, bKnown, ret
+}
+
+type settingMap map[string]BuildSetting
+
+// uniqSettingKeys matches build settings by key. The Go version and main
+// module come first, followed by the build settings in the order the go
+// command records them and the dependencies sorted by path.
+func uniqSettingKeys(a, b []BuildSetting) (settingMap, settingMap, []string) {
+	aKnown := make(settingMap, len(a))
+	bKnown := make(settingMap, len(b))
+	ret := make([]string, 0, len(a))
+	for _, an := range a {
+		aKnown[an.Key] = an
+		ret = append(ret, an.Key)
+	}
+	for _, bn := range b {
+		bKnown[bn.Key] = bn
+		if _, ok := aKnown[bn.Key]; !ok {
+			ret = append(ret, bn.Key)
+		}
+	}
+	rank := func(key string) int {
+		switch {
+		case key == "go":
+			return 0
+		case key == "path":
+			return 1
+		case key == "mod":
+			return 2
+		case strings.HasPrefix(key, "dep "):
+			return 4
+		}
+		return 3
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		ri, rj := rank(ret[i]), rank(ret[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return ri == 4 && ret[i] < ret[j]
+	})
+	return aKnown, bKnown, ret
+}
+
+type memberMap map[string]ar.Member
+
+func uniqMemberNames(a, b []ar.Member) (memberMap, memberMap, []string) {
+	names := make(map[string]struct{}, len(a))
+	aKnown := make(memberMap, len(a))
+	bKnown := make(memberMap, len(b))
+	for _, an := range a {
+		aKnown[an.Name] = an
+		names[an.Name] = struct{}{}
+	}
+	for _, bn := range b {
+		bKnown[bn.Name] = bn
+		names[bn.Name] = struct{}{}
+	}
+	ret := make([]string, 0, len(names))
+	for n := range names {
+		ret = append(ret, n)
+	}
+	sort.Strings(ret)
+	return aKnown, bKnown, ret
+}
+
+type strMap map[string]nm.String
+
+// uniqStrings returns the strings of both binaries by value, the largest
+// first.
+func uniqStrings(a, b []nm.String) (strMap, strMap, []string) {
+	values := make(map[string]struct{}, len(a))
+	aKnown := make(map[string]nm.String, len(a))
+	bKnown := make(map[string]nm.String, len(b))
+	for _, as := range a {
+		aKnown[as.Value] = as
+		values[as.Value] = struct{}{}
+	}
+	for _, bs := range b {
+		bKnown[bs.Value] = bs
+		values[bs.Value] = struct{}{}
+	}
+	ret := make([]string, 0, len(values))
+	for v := range values {
+		ret = append(ret, v)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if len(ret[i]) != len(ret[j]) {
+			return len(ret[i]) > len(ret[j])
+		}
+		return ret[i] < ret[j]
+	})
+	return aKnown, bKnown, ret
+}
+
+// resplitStrings returns the strings that are only in a or only in b
+// because the same bytes were split into strings differently, as happens
+// when nothing refers to the start of a string and it stays with the
+// string before it. Runs of adjacent strings only in a are matched to runs
+// only in b with the same bytes.
+func resplitStrings(a, b strMap) map[string]bool {
+	only := func(m, other strMap) []nm.String {
+		var ret []nm.String
+		for v, s := range m {
+			if _, ok := other[v]; !ok {
+				ret = append(ret, s)
+			}
+		}
+		sort.Slice(ret, func(i, j int) bool { return ret[i].Address < ret[j].Address })
+		return ret
+	}
+	removed, added := only(a, b), only(b, a)
+	// adjacent reports whether strs[i] directly follows strs[i-1]
+	adjacent := func(strs []nm.String, i int) bool {
+		return i < len(strs) && strs[i].Address == strs[i-1].Address+int64(len(strs[i-1].Value))
+	}
+	ret := map[string]bool{}
+	for i := range removed {
+		for j := range added {
+			if ret[removed[i].Value] || ret[added[j].Value] {
+				continue
+			}
+			ia, jb := i, j
+			sa, sb := removed[i].Value, added[j].Value
+			for sa != sb {
+				if strings.HasPrefix(sa, sb) && adjacent(added, jb+1) {
+					jb++
+					sb += added[jb].Value
+				} else if strings.HasPrefix(sb, sa) && adjacent(removed, ia+1) {
+					ia++
+					sa += removed[ia].Value
+				} else {
+					break
+				}
+			}
+			if sa != sb {
+				continue
+			}
+			for k := i; k <= ia; k++ {
+				ret[removed[k].Value] = true
+			}
+			for k := j; k <= jb; k++ {
+				ret[added[k].Value] = true
+			}
+		}
+	}
+	return ret
+}
+
+// withoutEmbeds returns the strings that don't overlap the name or
+// contents of one of the embedded files.
+func withoutEmbeds(strs []nm.String, embeds []nm.Embed) []nm.String {
+	if len(embeds) == 0 {
+		return strs
+	}
+	type extent struct{ start, end int64 }
+	var embedded []extent
+	for _, e := range embeds {
+		embedded = append(embedded, extent{e.NameAddress, e.NameAddress + int64(len(e.Name))})
+		if !e.IsDir() {
+			embedded = append(embedded, extent{e.Address, e.Address + e.Size})
+		}
+	}
+	// merge the extents, so that their ends are sorted too
+	sort.Slice(embedded, func(i, j int) bool { return embedded[i].start < embedded[j].start })
+	merged := embedded[:0]
+	for _, e := range embedded {
+		if n := len(merged); n > 0 && e.start <= merged[n-1].end {
+			if e.end > merged[n-1].end {
+				merged[n-1].end = e.end
+			}
+			continue
+		}
+		merged = append(merged, e)
+	}
+	var ret []nm.String
+	for _, s := range strs {
+		i := sort.Search(len(merged), func(i int) bool { return merged[i].end > s.Address })
+		if i < len(merged) && merged[i].start < s.Address+int64(len(s.Value)) {
+			continue
+		}
+		ret = append(ret, s)
+	}
+	return ret
+}
+
+type embedMap map[string]nm.Embed
+
+// embedKey identifies an embedded file across binaries by its variable and
+// path, like "main.assets:static/index.html".
+func embedKey(e nm.Embed) string {
+	return e.Var + ":" + e.Name
+}
+
+func uniqEmbedNames(a, b []nm.Embed) (embedMap, embedMap, []string) {
+	names := make(map[string]struct{}, len(a))
+	aKnown := make(map[string]nm.Embed, len(a))
+	bKnown := make(map[string]nm.Embed, len(b))
+	for _, ae := range a {
+		aKnown[embedKey(ae)] = ae
+		names[embedKey(ae)] = struct{}{}
+	}
+	for _, be := range b {
+		bKnown[embedKey(be)] = be
+		names[embedKey(be)] = struct{}{}
+	}
+	ret := make([]string, 0, len(names))
+	for n := range names {
+		ret = append(ret, n)
+	}
+	sort.Strings(ret)
+	return aKnown, bKnown, ret
+}
+
+type callMap map[string]inline.Call
+
+// uniqCalleeNames returns the inlined calls of a function in both binaries
+// by callee, and the callees sorted by name.
+func uniqCalleeNames(a, b inline.Function) (callMap, callMap, []string) {
+	names := make(map[string]struct{}, len(a.Inlined))
+	aKnown := make(map[string]inline.Call, len(a.Inlined))
+	bKnown := make(map[string]inline.Call, len(b.Inlined))
+	for _, ac := range a.Inlined {
+		aKnown[ac.Callee] = ac
+		names[ac.Callee] = struct{}{}
+	}
+	for _, bc := range b.Inlined {
+		bKnown[bc.Callee] = bc
+		names[bc.Callee] = struct{}{}
+	}
+	ret := make([]string, 0, len(names))
+	for n := range names {
+		ret = append(ret, n)
+	}
+	sort.Strings(ret)
+	return aKnown, bKnown, ret
+}
@@ -0,0 +1,110 @@
+package cmp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tzneal/bincmp/nm"
+)
+
+func TestMatchRenames(t *testing.T) {
+	a := []nm.Symbol{
+		{Name: "main.table", Kind: nm.KindData, Size: 16, Hash: "t"},
+		{Name: "main.process", Kind: nm.KindText, Size: 157, Hash: "p1"},
+		{Name: "main.helper", Kind: nm.KindText, Size: 40, Hash: "h1"},
+		{Name: "main.gone", Kind: nm.KindText, Size: 40, Hash: "g"},
+	}
+	b := []nm.Symbol{
+		{Name: "main.entries", Kind: nm.KindData, Size: 16, Hash: "t"},
+		// moved functions that make calls hash differently
+		{Name: "example.com/m/sub.Process", Kind: nm.KindText, Size: 157, Hash: "p2"},
+		{Name: "example.com/m/sub.Helper", Kind: nm.KindText, Size: 40, Hash: "h2"},
+		{Name: "main.added", Kind: nm.KindText, Size: 40, Hash: "n"},
+	}
+	sameCode := func(symA, symB nm.Symbol) bool {
+		return symA.Name == "main.process" && symB.Name == "example.com/m/sub.Process" ||
+			symA.Name == "main.helper" && symB.Name == "example.com/m/sub.Helper"
+	}
+	aKnown, bKnown, names := uniqSymNames(a, b)
+	got := matchRenames(aKnown, bKnown, names, sameCode)
+	exp := map[string]string{
+		"main.table":   "main.entries",
+		"main.process": "example.com/m/sub.Process",
+		"main.helper":  "example.com/m/sub.Helper",
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+
+	// without sameCode only the contents are compared
+	got = matchRenames(aKnown, bKnown, names, nil)
+	exp = map[string]string{"main.table": "main.entries"}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+}
+
+func TestRenameCandidates(t *testing.T) {
+	syms := func(names ...string) []nm.Symbol {
+		var ret []nm.Symbol
+		for _, n := range names {
+			ret = append(ret, nm.Symbol{Name: n})
+		}
+		return ret
+	}
+	for _, tc := range []struct {
+		a, b []nm.Symbol
+		exp  bool
+	}{
+		{syms("main.a", "main.b"), syms("main.a", "main.b"), false},
+		{syms("main.a", "main.b"), syms("main.a"), false},
+		{syms("main.a"), syms("main.a", "main.b"), false},
+		{syms("main.a", "main.b"), syms("main.a", "main.c"), true},
+	} {
+		if got := renameCandidates(tc.a, tc.b); got != tc.exp {
+			t.Errorf("%v, %v: expected %v, got %v", tc.a, tc.b, tc.exp, got)
+		}
+	}
+}
+
+func TestResplitStrings(t *testing.T) {
+	a := []nm.String{
+		{Value: "usage: ", Address: 0x100},
+		{Value: "tool [flags]", Address: 0x107},
+		{Value: "old help", Address: 0x200},
+		{Value: "kept", Address: 0x300},
+	}
+	// "usage: tool [flags]" lost the reference splitting it, and an
+	// unrelated string changed next to it
+	b := []nm.String{
+		{Value: "usage: tool [flags]", Address: 0x180},
+		{Value: "new help", Address: 0x193},
+		{Value: "kept", Address: 0x310},
+	}
+	aKnown, bKnown, _ := uniqStrings(a, b)
+	got := resplitStrings(aKnown, bKnown)
+	exp := map[string]bool{"usage: ": true, "tool [flags]": true, "usage: tool [flags]": true}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+}
+
+func TestWithoutEmbeds(t *testing.T) {
+	strs := []nm.String{
+		{Value: "usage: tool", Address: 0x100},
+		{Value: "static/app.js", Address: 0x10b},
+		{Value: "console.log(1)", Address: 0x118},
+		{Value: "kept", Address: 0x200},
+		// the end of a file, split from its start
+		{Value: "</html>", Address: 0x300},
+	}
+	embeds := []nm.Embed{
+		{Var: "main.assets", Name: "static/", NameAddress: 0x10b},
+		{Var: "main.assets", Name: "static/app.js", Size: 14, NameAddress: 0x10b, Address: 0x118},
+		{Var: "main.assets", Name: "static/index.html", Size: 0x20, NameAddress: 0x400, Address: 0x2e7},
+	}
+	exp := []nm.String{strs[0], strs[3]}
+	if got := withoutEmbeds(strs, embeds); !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected %q, got %q", exp, got)
+	}
+}
@@ -3,9 +3,14 @@ package cmp
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
+	"github.com/tzneal/bincmp/ar"
+	"github.com/tzneal/bincmp/inline"
 	"github.com/tzneal/bincmp/nm"
 	"github.com/tzneal/bincmp/objdump"
 	"github.com/tzneal/bincmp/readelf"
@@ -15,27 +20,89 @@ import (
 type Writer interface {
 	StartFiles(a, b os.FileInfo) error
 
+	StartBuildInfo()
+	WriteBuildSetting(setA, setB BuildSetting) error
+	EndBuildInfo()
+
+	StartMembers()
+	WriteMember(memA, memB ar.Member) error
+	EndMembers()
+
 	StartSymbols()
 	WriteSymbol(symA, symB nm.Symbol) error
 	WriteDisassembly(fnA, fnB objdump.Function) error
 	EndSymbols()
 
+	// StartGroups starts a report of symbols grouped the way named by by,
+	// e.g. "namespace"
+	StartGroups(by string)
+	WriteGroup(grpA, grpB Group) error
+	EndGroups()
+
+	// StartTree starts a report of groups nested by their path segments,
+	// with the groups of WriteTreeNode named by their full path
+	StartTree(by string)
+	WriteTreeNode(level int, grpA, grpB Group) error
+	EndTree()
+
+	StartStrings()
+	WriteString(strA, strB nm.String) error
+	EndStrings()
+
+	StartEmbeds()
+	WriteEmbed(embA, embB nm.Embed) error
+	EndEmbeds()
+
+	// StartInlining starts a report of the calls of functions fn that were
+	// only inlined in one of the binaries, passed to WriteInlined
+	StartInlining()
+	WriteInlined(fn string, callA, callB inline.Call) error
+	EndInlining()
+
 	StartSections()
 	WriteSection(sectA, sectB readelf.Section) error
 	EndSections()
+
+	StartImports()
+	WriteImport(impA, impB nm.Import) error
+	EndImports()
+
+	StartVersions()
+	WriteVersion(verA, verB nm.Import) error
+	EndVersions()
+
+	StartExports()
+	WriteExport(expA, expB nm.Export) error
+	EndExports()
 }
 
 var DefaultWriter Writer = &stdoutWriter{}
 
 const MaxSymLen = 60
 
+// MaxStrLen is the number of characters of a quoted string shown in the
+// string report
+const MaxStrLen = 40
+
 type stdoutWriter struct {
 	w      *tabwriter.Writer
 	totals [3]int64
+	// started is set once a report was written, to separate the next one
+	started bool
+}
+
+// table starts the table of a report, separated by a blank line from the
+// report before it, so that reports with nothing to show leave no gap.
+func (s *stdoutWriter) table() *tabwriter.Writer {
+	if s.started {
+		fmt.Println()
+	}
+	s.started = true
+	return tabwriter.NewWriter(os.Stdout, 2, 2, 2, ' ', 0)
 }
 
 func (s *stdoutWriter) StartFiles(a, b os.FileInfo) error {
-	w := tabwriter.NewWriter(os.Stdout, 2, 2, 2, ' ', 0)
+	w := s.table()
 	defer w.Flush()
 	fmt.Fprintf(w, "binary\tdelta\told\tnew\n")
 	delta := b.Size() - a.Size()
@@ -44,8 +111,65 @@ func (s *stdoutWriter) StartFiles(a, b os.FileInfo) error {
 	return nil
 }
 
+func (s *stdoutWriter) StartBuildInfo() {
+	s.w = s.table()
+	fmt.Fprintf(s.w, "build\told\tnew\tchange\n")
+}
+
+func (s *stdoutWriter) WriteBuildSetting(setA, setB BuildSetting) error {
+	key, change := setA.Key, "changed"
+	switch {
+	case setA.IsEmpty():
+		key, change = setB.Key, "added"
+	case setB.IsEmpty():
+		change = "removed"
+	}
+	fmt.Fprintf(s.w, "%s\t%s\t%s\t%s\n", key, setA.Value, setB.Value, change)
+	return nil
+}
+
+func (s *stdoutWriter) EndBuildInfo() {
+	s.w.Flush()
+	s.w = nil
+}
+
+func (s *stdoutWriter) StartMembers() {
+	s.w = s.table()
+	fmt.Fprintf(s.w, "member\tdelta\told\tnew\n")
+	s.totals = [3]int64{}
+}
+
+func (s *stdoutWriter) WriteMember(memA, memB ar.Member) error {
+	if !memA.IsEmpty() && !memB.IsEmpty() {
+		delta := memB.Size - memA.Size
+		pct := (float64(memB.Size)/float64(memA.Size) - 1) * 100
+		fmt.Fprintf(s.w, "%s\t%d\t%d\t%d\t%10.2f%%\n", memA.Name, delta, memA.Size, memB.Size, pct)
+		s.totals[0] += delta
+		s.totals[1] += memA.Size
+		s.totals[2] += memB.Size
+	} else if !memA.IsEmpty() {
+		delta := -memA.Size
+		fmt.Fprintf(s.w, "%s\t%d\t%d\t\n", memA.Name, delta, memA.Size)
+		s.totals[0] += delta
+		s.totals[1] += memA.Size
+	} else if !memB.IsEmpty() {
+		delta := memB.Size
+		fmt.Fprintf(s.w, "%s\t%d\t\t%d\n", memB.Name, delta, memB.Size)
+		s.totals[0] += delta
+		s.totals[2] += memB.Size
+	}
+	return nil
+}
+
+func (s *stdoutWriter) EndMembers() {
+	pct := (float64(s.totals[2])/float64(s.totals[1]) - 1) * 100
+	fmt.Fprintf(s.w, "total\t%d\t%d\t%d\t%10.2f%%\n", s.totals[0], s.totals[1], s.totals[2], pct)
+	s.w.Flush()
+	s.w = nil
+}
+
 func (s *stdoutWriter) StartSymbols() {
-	s.w = tabwriter.NewWriter(os.Stdout, 2, 2, 2, ' ', 0)
+	s.w = s.table()
 	fmt.Fprintf(s.w, "symbol name\tdelta\told\tnew\n")
 	s.totals = [3]int64{}
 }
@@ -65,13 +189,13 @@ func (s *stdoutWriter) WriteDisassembly(fnA, fnB objdump.Function) error {
 		aAsm := ""
 		aOff := ""
 		if i < len(fnA.Asm) {
-			aAsm = fnA.Asm[i].Asm
+			aAsm = fnA.Asm[i].Code()
 			aOff = fmt.Sprintf("0x%x", fnA.Asm[i].Offset)
 		}
 		bAsm := ""
 		bOff := ""
 		if i < len(fnB.Asm) {
-			bAsm = fnB.Asm[i].Asm
+			bAsm = fnB.Asm[i].Code()
 			bOff = fmt.Sprintf("0x%x", fnB.Asm[i].Offset)
 		}
 
@@ -97,20 +221,21 @@ func (s *stdoutWriter) WriteSymbol(symA, symB nm.Symbol) error {
 	// pick a non-empty name here (otherwise we would see an empty name
 	// in a report, which is not helpful).
 	var symName string
-	if symA.Name != "" {
-		symName = symA.Name
-	} else if symB.Name != "" {
-		symName = symB.Name
-	} else {
+	switch {
+	case !symA.IsEmpty() && !symB.IsEmpty() && symKey(symA) != symKey(symB):
+		symName = "renamed " + shorten(symbolName(symA), MaxSymLen/2) + " -> " + shorten(symbolName(symB), MaxSymLen/2)
+	case symA.Name != "":
+		symName = shorten(symbolName(symA), MaxSymLen)
+	case symB.Name != "":
+		symName = shorten(symbolName(symB), MaxSymLen)
+	default:
 		symName = "<?>" // Should never happen
 	}
-	if len(symName) > MaxSymLen {
-		symName = symName[0:MaxSymLen/2] + "..." + symName[len(symName)-MaxSymLen/2-3:]
-	}
 	if !symA.IsEmpty() && !symB.IsEmpty() {
 		delta := symB.Size - symA.Size
 		pct := (float64(symB.Size)/float64(symA.Size) - 1) * 100
-		fmt.Fprintf(s.w, "%s\t%d\t%d\t%d\t%10.2f%%\n", symName, delta, symA.Size, symB.Size, pct)
+		fmt.Fprintf(s.w, "%s\t%d\t%d\t%d\t%10.2f%%%s\n", symName, delta, symA.Size, symB.Size, pct,
+			sameSizeNote(symA.Size, symB.Size, symA.Hash, symB.Hash))
 		s.totals[0] += delta
 		s.totals[1] += symA.Size
 		s.totals[2] += symB.Size
@@ -129,6 +254,37 @@ func (s *stdoutWriter) WriteSymbol(symA, symB nm.Symbol) error {
 	return nil
 }
 
+// sameSizeNote marks symbols and sections whose contents changed while
+// their size didn't.
+func sameSizeNote(sizeA, sizeB int64, hashA, hashB string) string {
+	if sizeA == sizeB && hashA != hashB {
+		return "\tmodified, same size"
+	}
+	return ""
+}
+
+// symbolName returns the name a symbol is shown with, demangled if
+// possible. Archive members are shown like nm shows them,
+// "member.o:symbol".
+func symbolName(sym nm.Symbol) string {
+	name := sym.Name
+	if sym.Demangled != "" {
+		name = sym.Demangled
+	}
+	if sym.Member != "" {
+		name = sym.Member + ":" + name
+	}
+	return name
+}
+
+// shorten elides the middle of names longer than max.
+func shorten(name string, max int) string {
+	if len(name) > max {
+		return name[0:max/2] + "..." + name[len(name)-max/2-3:]
+	}
+	return name
+}
+
 func (s *stdoutWriter) EndSymbols() {
 	pct := (float64(s.totals[2])/float64(s.totals[1]) - 1) * 100
 	fmt.Fprintf(s.w, "total\t%d\t%d\t%d\t%10.2f%%\n", s.totals[0], s.totals[1], s.totals[2], pct)
@@ -136,8 +292,231 @@ func (s *stdoutWriter) EndSymbols() {
 	s.w = nil
 }
 
+func (s *stdoutWriter) StartGroups(by string) {
+	s.w = s.table()
+	fmt.Fprintf(s.w, "%s\tdelta\told\tnew\n", by)
+	s.totals = [3]int64{}
+}
+
+func (s *stdoutWriter) WriteGroup(grpA, grpB Group) error {
+	name := grpA.Name
+	if name == "" {
+		name = grpB.Name
+	}
+	s.writeGroupRow(shorten(name, MaxSymLen), grpA, grpB, true)
+	return nil
+}
+
+// writeGroupRow writes a row of the group or tree report, adding it to the
+// totals if total is set.
+func (s *stdoutWriter) writeGroupRow(name string, grpA, grpB Group, total bool) {
+	version := groupVersion(grpA, grpB)
+	if version != "" {
+		version = "\t" + version
+	}
+	var totals [3]int64
+	if !grpA.IsEmpty() && !grpB.IsEmpty() {
+		delta := grpB.Size - grpA.Size
+		pct := (float64(grpB.Size)/float64(grpA.Size) - 1) * 100
+		fmt.Fprintf(s.w, "%s\t%d\t%d\t%d\t%10.2f%%%s\n", name, delta, grpA.Size, grpB.Size, pct, version)
+		totals = [3]int64{delta, grpA.Size, grpB.Size}
+	} else if !grpA.IsEmpty() {
+		delta := -grpA.Size
+		fmt.Fprintf(s.w, "%s\t%d\t%d\t\t%s\n", name, delta, grpA.Size, version)
+		totals = [3]int64{delta, grpA.Size, 0}
+	} else if !grpB.IsEmpty() {
+		delta := grpB.Size
+		fmt.Fprintf(s.w, "%s\t%d\t\t%d\t%s\n", name, delta, grpB.Size, version)
+		totals = [3]int64{delta, 0, grpB.Size}
+	}
+	if total {
+		for i := range totals {
+			s.totals[i] += totals[i]
+		}
+	}
+}
+
+// groupVersion describes the versions of a group, "v1 -> v2" if it changed.
+func groupVersion(grpA, grpB Group) string {
+	switch {
+	case grpA.IsEmpty():
+		return grpB.Version
+	case grpB.IsEmpty(), grpA.Version == grpB.Version:
+		return grpA.Version
+	case grpA.Version == "":
+		return "-> " + grpB.Version
+	case grpB.Version == "":
+		return grpA.Version + " ->"
+	}
+	return grpA.Version + " -> " + grpB.Version
+}
+
+func (s *stdoutWriter) EndGroups() {
+	pct := (float64(s.totals[2])/float64(s.totals[1]) - 1) * 100
+	fmt.Fprintf(s.w, "total\t%d\t%d\t%d\t%10.2f%%\n", s.totals[0], s.totals[1], s.totals[2], pct)
+	s.w.Flush()
+	s.w = nil
+}
+
+func (s *stdoutWriter) StartTree(by string) {
+	s.StartGroups(by)
+}
+
+// WriteTreeNode indents the last path segment of the group name by its
+// level. Only the top level counts towards the totals, as the nodes below
+// are part of it.
+func (s *stdoutWriter) WriteTreeNode(level int, grpA, grpB Group) error {
+	name := grpA.Name
+	if name == "" {
+		name = grpB.Name
+	}
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	s.writeGroupRow(strings.Repeat("  ", level)+name, grpA, grpB, level == 0)
+	return nil
+}
+
+func (s *stdoutWriter) EndTree() {
+	s.EndGroups()
+}
+
+func (s *stdoutWriter) StartStrings() {
+	s.w = s.table()
+	fmt.Fprintf(s.w, "string\tdelta\told\tnew\n")
+	s.totals = [3]int64{}
+}
+
+// WriteString shows a string quoted, cut off after MaxStrLen characters.
+func (s *stdoutWriter) WriteString(strA, strB nm.String) error {
+	if !strA.IsEmpty() {
+		size := int64(len(strA.Value))
+		fmt.Fprintf(s.w, "%s\t%d\t%d\t\n", preview(strA.Value, MaxStrLen), -size, size)
+		s.totals[0] -= size
+		s.totals[1] += size
+	} else if !strB.IsEmpty() {
+		size := int64(len(strB.Value))
+		fmt.Fprintf(s.w, "%s\t%d\t\t%d\n", preview(strB.Value, MaxStrLen), size, size)
+		s.totals[0] += size
+		s.totals[2] += size
+	}
+	return nil
+}
+
+// preview quotes a string, cut off between two characters once the quoted
+// characters are wider than max, so that binary data, whose bytes quote to
+// escapes like \xff, takes as much room as text.
+func preview(str string, max int) string {
+	var b strings.Builder
+	width := 0
+	for len(str) > 0 {
+		_, size := utf8.DecodeRuneInString(str)
+		q := strconv.Quote(str[:size])
+		q = q[1 : len(q)-1]
+		width += utf8.RuneCountInString(q)
+		if width > max {
+			return `"` + b.String() + `"...`
+		}
+		b.WriteString(q)
+		str = str[size:]
+	}
+	return `"` + b.String() + `"`
+}
+
+func (s *stdoutWriter) EndStrings() {
+	fmt.Fprintf(s.w, "total\t%d\t%d\t%d\n", s.totals[0], s.totals[1], s.totals[2])
+	s.w.Flush()
+	s.w = nil
+}
+
+func (s *stdoutWriter) StartEmbeds() {
+	s.w = s.table()
+	fmt.Fprintf(s.w, "embedded file\tdelta\told\tnew\n")
+	s.totals = [3]int64{}
+}
+
+// WriteEmbed shows files by their variable and path, and directories that
+// were added or removed without sizes.
+func (s *stdoutWriter) WriteEmbed(embA, embB nm.Embed) error {
+	name := embedKey(embA)
+	if embA.IsEmpty() {
+		name = embedKey(embB)
+	}
+	switch {
+	case embA.IsDir() || embB.IsDir():
+		change := "added"
+		if embB.IsEmpty() {
+			change = "removed"
+		}
+		fmt.Fprintf(s.w, "%s\t\t\t\t%s\n", name, change)
+	case !embA.IsEmpty() && !embB.IsEmpty():
+		delta := embB.Size - embA.Size
+		pct := (float64(embB.Size)/float64(embA.Size) - 1) * 100
+		fmt.Fprintf(s.w, "%s\t%d\t%d\t%d\t%10.2f%%%s\n", name, delta, embA.Size, embB.Size, pct,
+			sameSizeNote(embA.Size, embB.Size, embA.Hash, embB.Hash))
+		s.totals[0] += delta
+		s.totals[1] += embA.Size
+		s.totals[2] += embB.Size
+	case !embA.IsEmpty():
+		delta := -embA.Size
+		fmt.Fprintf(s.w, "%s\t%d\t%d\t\n", name, delta, embA.Size)
+		s.totals[0] += delta
+		s.totals[1] += embA.Size
+	case !embB.IsEmpty():
+		delta := embB.Size
+		fmt.Fprintf(s.w, "%s\t%d\t\t%d\n", name, delta, embB.Size)
+		s.totals[0] += delta
+		s.totals[2] += embB.Size
+	}
+	return nil
+}
+
+func (s *stdoutWriter) EndEmbeds() {
+	pct := (float64(s.totals[2])/float64(s.totals[1]) - 1) * 100
+	fmt.Fprintf(s.w, "total\t%d\t%d\t%d\t%10.2f%%\n", s.totals[0], s.totals[1], s.totals[2], pct)
+	s.w.Flush()
+	s.w = nil
+}
+
+func (s *stdoutWriter) StartInlining() {
+	s.w = s.table()
+	fmt.Fprintf(s.w, "function\tinlined call\tdelta\told\tnew\tchange\n")
+	s.totals = [3]int64{}
+}
+
+func (s *stdoutWriter) WriteInlined(fn string, callA, callB inline.Call) error {
+	name := shorten(fn, MaxSymLen/2)
+	switch {
+	case !callA.IsEmpty():
+		fmt.Fprintf(s.w, "%s\t%s\t%d\t%d\t\tno longer inlined\n", name, calleeName(callA), -callA.Size, callA.Size)
+		s.totals[0] -= callA.Size
+		s.totals[1] += callA.Size
+	case !callB.IsEmpty():
+		fmt.Fprintf(s.w, "%s\t%s\t%d\t\t%d\tinlined\n", name, calleeName(callB), callB.Size, callB.Size)
+		s.totals[0] += callB.Size
+		s.totals[2] += callB.Size
+	}
+	return nil
+}
+
+// calleeName shows the number of calls that were inlined if there are
+// several.
+func calleeName(call inline.Call) string {
+	name := shorten(call.Callee, MaxSymLen/2)
+	if call.Count > 1 {
+		name += fmt.Sprintf(" (%d calls)", call.Count)
+	}
+	return name
+}
+
+func (s *stdoutWriter) EndInlining() {
+	fmt.Fprintf(s.w, "total\t\t%d\t%d\t%d\n", s.totals[0], s.totals[1], s.totals[2])
+	s.w.Flush()
+	s.w = nil
+}
+
 func (s *stdoutWriter) StartSections() {
-	s.w = tabwriter.NewWriter(os.Stdout, 2, 2, 2, ' ', 0)
+	s.w = s.table()
 	fmt.Fprintf(s.w, "name\tdelta\told\tnew\n")
 	s.totals = [3]int64{}
 }
@@ -146,7 +525,8 @@ func (s *stdoutWriter) WriteSection(sectA, sectB readelf.Section) error {
 	if !sectA.IsEmpty() && !sectB.IsEmpty() {
 		delta := sectB.Size - sectA.Size
 		pct := (float64(sectB.Size)/float64(sectA.Size) - 1) * 100
-		fmt.Fprintf(s.w, "%s\t%d\t%d\t%d\t%10.2f%%\n", sectA.Name, delta, sectA.Size, sectB.Size, pct)
+		fmt.Fprintf(s.w, "%s\t%d\t%d\t%d\t%10.2f%%%s\n", sectA.Name, delta, sectA.Size, sectB.Size, pct,
+			sameSizeNote(sectA.Size, sectB.Size, sectA.Hash, sectB.Hash))
 		s.totals[0] += delta
 		s.totals[1] += sectA.Size
 		s.totals[2] += sectB.Size
@@ -170,3 +550,96 @@ func (s *stdoutWriter) EndSections() {
 	s.w.Flush()
 	s.w = nil
 }
+
+func (s *stdoutWriter) StartImports() {
+	s.w = s.table()
+	fmt.Fprintf(s.w, "library\tsymbol\tchange\n")
+}
+
+func (s *stdoutWriter) WriteImport(impA, impB nm.Import) error {
+	switch {
+	case impA.IsEmpty() && !impB.IsEmpty():
+		fmt.Fprintf(s.w, "%s\t%s\tadded\n", impB.Library, impB.Symbol())
+	case !impA.IsEmpty() && impB.IsEmpty():
+		fmt.Fprintf(s.w, "%s\t%s\tremoved\n", impA.Library, impA.Symbol())
+	}
+	return nil
+}
+
+func (s *stdoutWriter) EndImports() {
+	s.w.Flush()
+	s.w = nil
+}
+
+func (s *stdoutWriter) StartVersions() {
+	s.w = s.table()
+	fmt.Fprintf(s.w, "requires\told\tnew\tchange\n")
+}
+
+func (s *stdoutWriter) WriteVersion(verA, verB nm.Import) error {
+	name, change := verA.Name, ""
+	switch {
+	case verA.IsEmpty():
+		name, change = verB.Name, "added"
+	case verB.IsEmpty():
+		change = "removed"
+	case verA.Version != verB.Version:
+		change = "changed"
+	}
+	fmt.Fprintf(s.w, "%s\t%s\t%s\t%s\n", name, verA.Version, verB.Version, change)
+	return nil
+}
+
+func (s *stdoutWriter) EndVersions() {
+	s.w.Flush()
+	s.w = nil
+}
+
+func (s *stdoutWriter) StartExports() {
+	s.w = s.table()
+	fmt.Fprintf(s.w, "export\tkind\tchange\n")
+}
+
+func (s *stdoutWriter) WriteExport(expA, expB nm.Export) error {
+	switch {
+	case expA.IsEmpty() && !expB.IsEmpty():
+		fmt.Fprintf(s.w, "%s\t%s\tadded\n", expB, expB.Kind)
+	case !expA.IsEmpty() && expB.IsEmpty():
+		fmt.Fprintf(s.w, "%s\t%s\tremoved\n", expA, expA.Kind)
+	case expA.Kind != expB.Kind:
+		fmt.Fprintf(s.w, "%s\t%s -> %s\tchanged\n", expA.Name, expA.Kind, expB.Kind)
+	default:
+		fmt.Fprintf(s.w, "%s\t%s\t%s\n", expA.Name, expA.Kind, exportChanges(expA, expB))
+	}
+	return nil
+}
+
+// exportChanges describes how an export that kept its kind changed.
+func exportChanges(expA, expB nm.Export) string {
+	var changes []string
+	if expA.Version != expB.Version || expA.HiddenVersion != expB.HiddenVersion {
+		// only show the "@version" part of the names
+		changes = append(changes, fmt.Sprintf("version %s -> %s",
+			strings.TrimPrefix(expA.String(), expA.Name), strings.TrimPrefix(expB.String(), expB.Name)))
+	}
+	if expA.Binding != expB.Binding {
+		changes = append(changes, fmt.Sprintf("binding %s -> %s",
+			bindingName(expA.Binding), bindingName(expB.Binding)))
+	}
+	if expA.Visibility != expB.Visibility {
+		changes = append(changes, fmt.Sprintf("visibility %s -> %s", expA.Visibility, expB.Visibility))
+	}
+	if expA.Size != expB.Size {
+		changes = append(changes, fmt.Sprintf("size %d -> %d", expA.Size, expB.Size))
+	}
+	return strings.Join(changes, ", ")
+}
+
+func bindingName(b nm.Binding) string {
+	return strings.ToLower(strings.TrimPrefix(b.String(), "Binding"))
+}
+
+func (s *stdoutWriter) EndExports() {
+	s.w.Flush()
+	s.w = nil
+}
@@ -0,0 +1,36 @@
+package cmp
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPreview(t *testing.T) {
+	for _, tc := range []struct {
+		str string
+		exp string
+	}{
+		{"help", `"help"`},
+		{"line\n", `"line\n"`},
+		{"0123456789", `"0123456789"`},
+		{"0123456789a", `"0123456789"...`},
+		// the escapes count towards the width
+		{"\xff\x00\xff\x00", `"\xff\x00"...`},
+		// not in the middle of a character or an escape
+		{"héllo wörld", `"héllo wörl"...`},
+		{"abcdefghi\n", `"abcdefghi"...`},
+	} {
+		if got := preview(tc.str, 10); got != tc.exp {
+			t.Errorf("%q: expected %s, got %s", tc.str, tc.exp, got)
+		}
+	}
+	// a binary table is no wider than text
+	table := strings.Repeat("\xff\x00", 100)
+	if got := preview(table, MaxStrLen); utf8.RuneCountInString(got) > MaxStrLen+5 {
+		t.Errorf("expected at most %d characters, got %d: %s", MaxStrLen+5, len(got), got)
+	}
+	if got := preview("日本語のテキスト", 4); !utf8.ValidString(got) {
+		t.Errorf("expected valid UTF-8, got %q", got)
+	}
+}
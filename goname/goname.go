@@ -0,0 +1,414 @@
+// Package goname splits the names the Go linker gives symbols into their
+// parts: package path, receiver type, function, closures, generic type
+// arguments and wrapper suffixes.
+package goname
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Kind is the kind of symbol a name denotes
+type Kind byte
+
+const (
+	// KindOther is anything not listed below, including the symbols of C
+	// code linked in with cgo
+	KindOther Kind = iota
+	// KindFunc is a function, method, closure or package variable, which
+	// can't be told apart by name
+	KindFunc
+	// KindType is a runtime type descriptor, "type:T"
+	KindType
+	// KindTypeEq and KindTypeHash are the equality and hash functions
+	// generated for a type, "type:.eq.T" and "type:.hash.T"
+	KindTypeEq
+	KindTypeHash
+	// KindTypeData is other data generated for a type, such as its name
+	KindTypeData
+	// KindItab is the interface table of a concrete type for an interface,
+	// "go:itab.T,I"
+	KindItab
+	// KindDict is the dictionary of a generic function instantiation,
+	// "pkg..dict.F[int]"
+	KindDict
+	// KindString is string data, "go:string.*"
+	KindString
+)
+
+var kindNames = []string{"other", "func", "type", "type eq", "type hash", "type data",
+	"itab", "dict", "string"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Name is a parsed symbol name.
+//
+// For "net/http.(*Server).Serve.func1" Package is "net/http", Receiver is
+// "Server", PtrReceiver is true, Func is "Serve" and Closures is ["func1"].
+type Name struct {
+	Kind Kind
+	// Package is the import path, unescaped. It is empty for the old "".
+	// form, which stands for the package being compiled, and for names that
+	// don't belong to a package.
+	Package string
+	// Receiver is the named type a method, type descriptor, type algorithm
+	// or itab belongs to, without its type arguments, and PtrReceiver is
+	// set if it is used through a pointer.
+	Receiver    string
+	PtrReceiver bool
+	// Func is the function or variable name. Symbols that aren't parsed any
+	// further have the whole name, or what follows their prefix, here.
+	Func string
+	// TypeArgs are the type arguments of a generic instantiation, of the
+	// receiver type if there is one, else of the function.
+	TypeArgs []string
+	// Closures lists the closures the symbol is nested in, outermost first,
+	// e.g. ["func1", "2"] for "pkg.F.func1.2", "gowrap1" for go statement
+	// wrappers and "range1" for range-over-func bodies.
+	Closures []string
+	// Interface is the interface of an itab.
+	Interface string
+	// ABI is the ABI suffix the linker adds when a function exists for both
+	// calling conventions, "abi0" or "abiinternal".
+	ABI string
+	// Wrapper is the kind of generated wrapper: "fm" for method values and
+	// "tramp" for linker trampolines.
+	Wrapper string
+	// Aux is the suffix of auxiliary function data, like "stkobj" or
+	// "arginfo1".
+	Aux string
+	// Old is set for names in the form used before Go 1.20, e.g. "type."
+	// rather than "type:", or with the "". package.
+	Old bool
+}
+
+// auxSuffixes are the auxiliary symbols the compiler generates for a
+// function
+var auxSuffixes = map[string]bool{
+	"stkobj":        true,
+	"arginfo0":      true,
+	"arginfo1":      true,
+	"argliveinfo":   true,
+	"args_stackmap": true,
+	"opendefer":     true,
+	"wrapinfo":      true,
+}
+
+// otherPrefixes are prefixes of runtime and linker generated symbols that
+// don't belong to a package
+var otherPrefixes = []string{
+	"go:", "go.buildid", "go.func.", "go.info.", "go.loc.", "go.range.",
+	"go.debuglines.", "go.cuinfo.", "go.importpath.", "go.link.", "go.map.",
+	"go.builtin.", "go.shape.", "go.constinfo.", "go.sym.", "go.weak.",
+	"gclocals·", "gcargs·", "$",
+}
+
+// Parse splits a Go symbol name. It never fails: names it doesn't recognize
+// are returned with KindOther and the whole name in Func.
+func Parse(sym string) Name {
+	switch {
+	case strings.HasPrefix(sym, "type:"):
+		return parseTypeSym(sym[len("type:"):], false)
+	case strings.HasPrefix(sym, "type."):
+		return parseTypeSym(sym[len("type."):], true)
+	case strings.HasPrefix(sym, "go:itab."):
+		return parseItab(sym[len("go:itab."):], false)
+	case strings.HasPrefix(sym, "go.itab."):
+		return parseItab(sym[len("go.itab."):], true)
+	case strings.HasPrefix(sym, "go:string."):
+		return Name{Kind: KindString, Func: sym[len("go:string."):]}
+	case strings.HasPrefix(sym, "go.string."):
+		return Name{Kind: KindString, Func: sym[len("go.string."):], Old: true}
+	}
+	// versioned names of dynamic symbols, as in "memcpy@GLIBC_2.14", are C
+	if strings.ContainsRune(sym, '@') {
+		return Name{Kind: KindOther, Func: sym}
+	}
+	for _, p := range otherPrefixes {
+		if strings.HasPrefix(sym, p) {
+			return Name{Kind: KindOther, Func: sym}
+		}
+	}
+
+	n := Name{Kind: KindFunc}
+	var rest string
+	if strings.HasPrefix(sym, `"".`) {
+		n.Old = true
+		rest = sym[len(`"".`):]
+	} else {
+		pkg, r, ok := splitPackage(sym)
+		if !ok {
+			return Name{Kind: KindOther, Func: sym}
+		}
+		n.Package, rest = pkg, r
+	}
+
+	if strings.HasPrefix(rest, ".dict.") {
+		n.Kind = KindDict
+		n.Func, n.TypeArgs = splitTypeArgs(rest[len(".dict."):])
+		return n
+	}
+
+	// a receiver in parentheses is always a method
+	if strings.HasPrefix(rest, "(") {
+		end := matching(rest, 0)
+		if end < 0 || !strings.HasPrefix(rest[end+1:], ".") {
+			return Name{Kind: KindOther, Func: sym}
+		}
+		recv := rest[1:end]
+		n.PtrReceiver = strings.HasPrefix(recv, "*")
+		n.Receiver, n.TypeArgs = splitTypeArgs(strings.TrimPrefix(recv, "*"))
+		n.parseFunc(splitTop(rest[end+2:], '.'), false)
+		return n
+	}
+	n.parseFunc(splitTop(rest, '.'), true)
+	return n
+}
+
+// parseFunc fills in the function, closures and suffixes from the dot
+// separated components following the package or receiver. If
+// valueReceiver is set, the first of two components may be the receiver
+// type of a method.
+func (n *Name) parseFunc(comps []string, valueReceiver bool) {
+	// wrapper suffixes follow the last component
+	last := comps[len(comps)-1]
+	if strings.HasSuffix(last, "-fm") {
+		n.Wrapper = "fm"
+		comps[len(comps)-1] = strings.TrimSuffix(last, "-fm")
+	} else if i := strings.LastIndex(last, "-tramp"); i > 0 && isDigits(last[i+len("-tramp"):]) {
+		n.Wrapper = "tramp"
+		comps[len(comps)-1] = last[:i]
+	}
+	for len(comps) > 1 {
+		last := comps[len(comps)-1]
+		if last == "abi0" || last == "abiinternal" {
+			n.ABI = last
+		} else if auxSuffixes[last] {
+			n.Aux = last
+		} else {
+			break
+		}
+		comps = comps[:len(comps)-1]
+	}
+
+	// range-over-func bodies are named "F-range1-range2", split them into
+	// components of their own
+	var split []string
+	for _, c := range comps {
+		if i := strings.Index(c, "-range"); i > 0 {
+			split = append(split, c[:i])
+			split = append(split, strings.Split(c[i+1:], "-")...)
+			continue
+		}
+		split = append(split, c)
+	}
+	comps = split
+
+	// the first closure ends the function name, nested ones may just be
+	// numbered
+	first := len(comps)
+	for i := 1; i < len(comps); i++ {
+		if isClosure(comps[i]) {
+			first = i
+			break
+		}
+	}
+	for _, c := range comps[first:] {
+		if !isClosure(c) && !isDigits(c) {
+			first = len(comps)
+			break
+		}
+	}
+	if first < len(comps) {
+		n.Closures = comps[first:]
+	}
+	comps = comps[:first]
+
+	if valueReceiver && len(comps) == 2 && isTypeName(comps[0]) && isTypeName(comps[1]) {
+		n.Receiver, n.TypeArgs = splitTypeArgs(comps[0])
+		comps = comps[1:]
+	}
+	fn := strings.Join(comps, ".")
+	if n.Receiver == "" {
+		fn, n.TypeArgs = splitTypeArgs(fn)
+	}
+	n.Func = fn
+}
+
+// parseTypeSym parses what follows the "type:" prefix of a type descriptor
+// or of data generated for a type, as in "type:.eq.main.T".
+func parseTypeSym(s string, old bool) Name {
+	n := Name{Kind: KindType, Old: old}
+	if strings.HasPrefix(s, ".") {
+		s = s[1:]
+		i := strings.IndexByte(s, '.')
+		if i < 0 {
+			return Name{Kind: KindTypeData, Func: s, Old: old}
+		}
+		switch s[:i] {
+		case "eq":
+			n.Kind = KindTypeEq
+		case "hash":
+			n.Kind = KindTypeHash
+		default:
+			n.Kind = KindTypeData
+		}
+		s = s[i+1:]
+	}
+	n.parseType(s)
+	return n
+}
+
+// parseItab parses "T,I", the concrete type and interface of an itab.
+func parseItab(s string, old bool) Name {
+	n := Name{Kind: KindItab, Old: old}
+	parts := splitTop(s, ',')
+	if len(parts) == 2 {
+		n.Interface = parts[1]
+	}
+	n.parseType(parts[0])
+	return n
+}
+
+// parseType parses a type expression. For named types, possibly behind
+// pointers, the package and type name are filled in; other types, like
+// "[]int" or "map[string]int", are only stored in Func.
+func (n *Name) parseType(s string) {
+	t := strings.TrimLeft(s, "*")
+	if pkg, rest, ok := splitPackage(t); ok && isTypeName(rest) && !strings.HasPrefix(t, "go.shape.") {
+		n.Package = pkg
+		n.PtrReceiver = len(t) < len(s)
+		n.Receiver, n.TypeArgs = splitTypeArgs(rest)
+		return
+	}
+	n.Func = s
+}
+
+// splitPackage splits "path/to/pkg.rest" at the dot ending the package path.
+// Dots in the last path element are escaped as %2e by the linker.
+func splitPackage(sym string) (pkg, rest string, ok bool) {
+	// the path ends before any parenthesis or bracket
+	end := len(sym)
+	if i := strings.IndexAny(sym, "([ "); i >= 0 {
+		end = i
+	}
+	slash := strings.LastIndexByte(sym[:end], '/')
+	dot := strings.IndexByte(sym[slash+1:end], '.')
+	if dot <= 0 {
+		return "", "", false
+	}
+	dot += slash + 1
+	return unescape(sym[:dot]), sym[dot+1:], true
+}
+
+// unescape undoes the escaping of import paths in symbol names.
+func unescape(path string) string {
+	if !strings.Contains(path, "%") {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '%' && i+2 < len(path) {
+			if c, err := strconv.ParseUint(path[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
+
+// splitTypeArgs splits "F[int,string]" into "F" and its type arguments.
+func splitTypeArgs(s string) (string, []string) {
+	i := strings.IndexByte(s, '[')
+	if i <= 0 || !strings.HasSuffix(s, "]") || matching(s, i) != len(s)-1 {
+		return s, nil
+	}
+	return s[:i], splitTop(s[i+1:len(s)-1], ',')
+}
+
+// splitTop splits s at the separators that aren't nested in parentheses,
+// brackets or braces.
+func splitTop(s string, sep byte) []string {
+	var ret []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case sep:
+			if depth == 0 {
+				ret = append(ret, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(ret, s[start:])
+}
+
+// matching returns the index of the bracket closing the one at s[open], or
+// -1.
+func matching(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// isClosure reports whether a name component is a closure the compiler
+// named: "func1", "gowrap1", "deferwrap1" or "range1".
+func isClosure(c string) bool {
+	for _, p := range []string{"func", "gowrap", "deferwrap", "range"} {
+		if strings.HasPrefix(c, p) && isDigits(c[len(p):]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isTypeName reports whether s is an identifier, possibly followed by type
+// arguments.
+func isTypeName(s string) bool {
+	name, _ := splitTypeArgs(s)
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c != '_' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') &&
+			!(c >= '0' && c <= '9') && c < 0x80 {
+			return false
+		}
+	}
+	return true
+}
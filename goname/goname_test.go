@@ -0,0 +1,209 @@
+package goname
+
+import (
+	"debug/elf"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		sym string
+		exp Name
+	}{
+		// plain functions and variables
+		{"main.main", Name{Kind: KindFunc, Package: "main", Func: "main"}},
+		{"runtime.mallocgc", Name{Kind: KindFunc, Package: "runtime", Func: "mallocgc"}},
+		{"unicode/utf8.first", Name{Kind: KindFunc, Package: "unicode/utf8", Func: "first"}},
+		{"internal/runtime/gc/scan.expandAVX512_12_mat1", Name{Kind: KindFunc, Package: "internal/runtime/gc/scan", Func: "expandAVX512_12_mat1"}},
+		{"encoding/xml.HTMLEntity", Name{Kind: KindFunc, Package: "encoding/xml", Func: "HTMLEntity"}},
+		{"github.com/spf13/cobra.(*Command).Execute", Name{Kind: KindFunc, Package: "github.com/spf13/cobra", Receiver: "Command", PtrReceiver: true, Func: "Execute"}},
+		{"go.uber.org/zap.New", Name{Kind: KindFunc, Package: "go.uber.org/zap", Func: "New"}},
+		{"go.uber.org/zap/zapcore.(*CheckedEntry).Write", Name{Kind: KindFunc, Package: "go.uber.org/zap/zapcore", Receiver: "CheckedEntry", PtrReceiver: true, Func: "Write"}},
+		{"vendor/golang.org/x/net/http2/hpack.(*Decoder).Write", Name{Kind: KindFunc, Package: "vendor/golang.org/x/net/http2/hpack", Receiver: "Decoder", PtrReceiver: true, Func: "Write"}},
+		{"gopkg.in/yaml%2ev3.(*parser).parse", Name{Kind: KindFunc, Package: "gopkg.in/yaml.v3", Receiver: "parser", PtrReceiver: true, Func: "parse"}},
+		{"gopkg.in/yaml%2ev3.Unmarshal", Name{Kind: KindFunc, Package: "gopkg.in/yaml.v3", Func: "Unmarshal"}},
+		{"github.com/aws/aws-sdk-go-v2/service/s3.(*Client).PutObject", Name{Kind: KindFunc, Package: "github.com/aws/aws-sdk-go-v2/service/s3", Receiver: "Client", PtrReceiver: true, Func: "PutObject"}},
+		{"k8s.io/api/core/v1.(*Pod).Marshal", Name{Kind: KindFunc, Package: "k8s.io/api/core/v1", Receiver: "Pod", PtrReceiver: true, Func: "Marshal"}},
+
+		// methods
+		{"net/http.(*Server).Serve", Name{Kind: KindFunc, Package: "net/http", Receiver: "Server", PtrReceiver: true, Func: "Serve"}},
+		{"net/http.Header.Get", Name{Kind: KindFunc, Package: "net/http", Receiver: "Header", Func: "Get"}},
+		{"runtime.gcTrigger.test", Name{Kind: KindFunc, Package: "runtime", Receiver: "gcTrigger", Func: "test"}},
+		{"fmt.(*fmt).pad", Name{Kind: KindFunc, Package: "fmt", Receiver: "fmt", PtrReceiver: true, Func: "pad"}},
+		{"internal/abi.(*Type).Kind", Name{Kind: KindFunc, Package: "internal/abi", Receiver: "Type", PtrReceiver: true, Func: "Kind"}},
+		{"io.Reader.Read", Name{Kind: KindFunc, Package: "io", Receiver: "Reader", Func: "Read"}},
+		{"time.Time.String", Name{Kind: KindFunc, Package: "time", Receiver: "Time", Func: "String"}},
+
+		// closures
+		{"net/http.(*Server).Serve.func1", Name{Kind: KindFunc, Package: "net/http", Receiver: "Server", PtrReceiver: true, Func: "Serve", Closures: []string{"func1"}}},
+		{"main.main.func1", Name{Kind: KindFunc, Package: "main", Func: "main", Closures: []string{"func1"}}},
+		{"main.main.func1.1", Name{Kind: KindFunc, Package: "main", Func: "main", Closures: []string{"func1", "1"}}},
+		{"main.main.func1.2.3", Name{Kind: KindFunc, Package: "main", Func: "main", Closures: []string{"func1", "2", "3"}}},
+		{"sync.OnceFunc.func1.1", Name{Kind: KindFunc, Package: "sync", Func: "OnceFunc", Closures: []string{"func1", "1"}}},
+		{"runtime.casgstatus.func3", Name{Kind: KindFunc, Package: "runtime", Func: "casgstatus", Closures: []string{"func3"}}},
+		{"net/http.Header.writeSubset.func1", Name{Kind: KindFunc, Package: "net/http", Receiver: "Header", Func: "writeSubset", Closures: []string{"func1"}}},
+		{"runtime.gcenable.gowrap1", Name{Kind: KindFunc, Package: "runtime", Func: "gcenable", Closures: []string{"gowrap1"}}},
+		{"main.main.func1.gowrap2", Name{Kind: KindFunc, Package: "main", Func: "main", Closures: []string{"func1", "gowrap2"}}},
+		{"fmt.(*pp).handleMethods.deferwrap1", Name{Kind: KindFunc, Package: "fmt", Receiver: "pp", PtrReceiver: true, Func: "handleMethods", Closures: []string{"deferwrap1"}}},
+		{"os.init.func1", Name{Kind: KindFunc, Package: "os", Func: "init", Closures: []string{"func1"}}},
+		{"main.glob..func1", Name{Kind: KindFunc, Package: "main", Func: "glob.", Closures: []string{"func1"}}},
+		{"main.F-range1", Name{Kind: KindFunc, Package: "main", Func: "F", Closures: []string{"range1"}}},
+		{"main.F-range1-range2", Name{Kind: KindFunc, Package: "main", Func: "F", Closures: []string{"range1", "range2"}}},
+		{"main.F.func1-range1", Name{Kind: KindFunc, Package: "main", Func: "F", Closures: []string{"func1", "range1"}}},
+
+		// init functions and compiler generated variables
+		{"main.init", Name{Kind: KindFunc, Package: "main", Func: "init"}},
+		{"main.init.0", Name{Kind: KindFunc, Package: "main", Func: "init.0"}},
+		{"main.init.1", Name{Kind: KindFunc, Package: "main", Func: "init.1"}},
+		{"time..inittask", Name{Kind: KindFunc, Package: "time", Func: ".inittask"}},
+		{"main..stmp_0", Name{Kind: KindFunc, Package: "main", Func: ".stmp_0"}},
+		{"runtime.gcbits.0100000000000000", Name{Kind: KindFunc, Package: "runtime", Func: "gcbits.0100000000000000"}},
+
+		// generics, Go 1.18 to 1.20 shapes are numbered
+		{"main.Map[go.shape.int_0,go.shape.string_1]", Name{Kind: KindFunc, Package: "main", Func: "Map", TypeArgs: []string{"go.shape.int_0", "go.shape.string_1"}}},
+		{"example.com/m.Map[go.shape.int,go.shape.string]", Name{Kind: KindFunc, Package: "example.com/m", Func: "Map", TypeArgs: []string{"go.shape.int", "go.shape.string"}}},
+		{"main.Keys[...]", Name{Kind: KindFunc, Package: "main", Func: "Keys", TypeArgs: []string{"..."}}},
+		{"slices.Sort[go.shape.[]uint8,go.shape.uint8]", Name{Kind: KindFunc, Package: "slices", Func: "Sort", TypeArgs: []string{"go.shape.[]uint8", "go.shape.uint8"}}},
+		{"example.com/m.F[go.shape.*example.com/m.T]", Name{Kind: KindFunc, Package: "example.com/m", Func: "F", TypeArgs: []string{"go.shape.*example.com/m.T"}}},
+		{"main.F[go.shape.struct { X int; Y string }]", Name{Kind: KindFunc, Package: "main", Func: "F", TypeArgs: []string{"go.shape.struct { X int; Y string }"}}},
+		{"main.F[go.shape.func(int, string) error]", Name{Kind: KindFunc, Package: "main", Func: "F", TypeArgs: []string{"go.shape.func(int, string) error"}}},
+		{"main.F[go.shape.map[string]int]", Name{Kind: KindFunc, Package: "main", Func: "F", TypeArgs: []string{"go.shape.map[string]int"}}},
+		{"main.F[go.shape.int].func1", Name{Kind: KindFunc, Package: "main", Func: "F", TypeArgs: []string{"go.shape.int"}, Closures: []string{"func1"}}},
+		{"main.List[go.shape.int].Len", Name{Kind: KindFunc, Package: "main", Receiver: "List", Func: "Len", TypeArgs: []string{"go.shape.int"}}},
+		{"main.(*List[go.shape.int]).Push", Name{Kind: KindFunc, Package: "main", Receiver: "List", PtrReceiver: true, Func: "Push", TypeArgs: []string{"go.shape.int"}}},
+		{"main.(*Pair[go.shape.int,go.shape.string]).Swap", Name{Kind: KindFunc, Package: "main", Receiver: "Pair", PtrReceiver: true, Func: "Swap", TypeArgs: []string{"go.shape.int", "go.shape.string"}}},
+		{"internal/sync.(*HashTrieMap[go.shape.interface {},go.shape.interface {}]).LoadOrStore.deferwrap1", Name{Kind: KindFunc, Package: "internal/sync", Receiver: "HashTrieMap", PtrReceiver: true, Func: "LoadOrStore", TypeArgs: []string{"go.shape.interface {}", "go.shape.interface {}"}, Closures: []string{"deferwrap1"}}},
+		{"main.(*T[go.shape.struct { X int }]).M", Name{Kind: KindFunc, Package: "main", Receiver: "T", PtrReceiver: true, Func: "M", TypeArgs: []string{"go.shape.struct { X int }"}}},
+		{"internal/sync..dict.HashTrieMap[interface {},interface {}]", Name{Kind: KindDict, Package: "internal/sync", Func: "HashTrieMap", TypeArgs: []string{"interface {}", "interface {}"}}},
+		{"main..dict.Map[int,string]", Name{Kind: KindDict, Package: "main", Func: "Map", TypeArgs: []string{"int", "string"}}},
+
+		// ABI and wrapper suffixes
+		{"runtime.memmove.abi0", Name{Kind: KindFunc, Package: "runtime", Func: "memmove", ABI: "abi0"}},
+		{"runtime.nanotime1.abi0", Name{Kind: KindFunc, Package: "runtime", Func: "nanotime1", ABI: "abi0"}},
+		{"syscall.Syscall6.abiinternal", Name{Kind: KindFunc, Package: "syscall", Func: "Syscall6", ABI: "abiinternal"}},
+		{"net/http.(*Server).Serve-fm", Name{Kind: KindFunc, Package: "net/http", Receiver: "Server", PtrReceiver: true, Func: "Serve", Wrapper: "fm"}},
+		{"main.T.M-fm", Name{Kind: KindFunc, Package: "main", Receiver: "T", Func: "M", Wrapper: "fm"}},
+		{"main.(*List[go.shape.int]).Push-fm", Name{Kind: KindFunc, Package: "main", Receiver: "List", PtrReceiver: true, Func: "Push", TypeArgs: []string{"go.shape.int"}, Wrapper: "fm"}},
+		{"runtime.morestack-tramp0", Name{Kind: KindFunc, Package: "runtime", Func: "morestack", Wrapper: "tramp"}},
+		{"main.f.arginfo1", Name{Kind: KindFunc, Package: "main", Func: "f", Aux: "arginfo1"}},
+		{"main.T.M.stkobj", Name{Kind: KindFunc, Package: "main", Receiver: "T", Func: "M", Aux: "stkobj"}},
+		{"main.(*T).M.opendefer", Name{Kind: KindFunc, Package: "main", Receiver: "T", PtrReceiver: true, Func: "M", Aux: "opendefer"}},
+		{"main.main.args_stackmap", Name{Kind: KindFunc, Package: "main", Func: "main", Aux: "args_stackmap"}},
+
+		// the "". package of object files before Go 1.20
+		{`"".main`, Name{Kind: KindFunc, Func: "main", Old: true}},
+		{`"".(*T).M`, Name{Kind: KindFunc, Receiver: "T", PtrReceiver: true, Func: "M", Old: true}},
+		{`"".T.M`, Name{Kind: KindFunc, Receiver: "T", Func: "M", Old: true}},
+		{`"".main.func1`, Name{Kind: KindFunc, Func: "main", Closures: []string{"func1"}, Old: true}},
+		{`"".glob..func1`, Name{Kind: KindFunc, Func: "glob.", Closures: []string{"func1"}, Old: true}},
+
+		// type descriptors and algorithms
+		{"type:*net/http.Server", Name{Kind: KindType, Package: "net/http", Receiver: "Server", PtrReceiver: true}},
+		{"type:net/http.Server", Name{Kind: KindType, Package: "net/http", Receiver: "Server"}},
+		{"type.*net/http.Server", Name{Kind: KindType, Package: "net/http", Receiver: "Server", PtrReceiver: true, Old: true}},
+		{"type.net/http.Header", Name{Kind: KindType, Package: "net/http", Receiver: "Header", Old: true}},
+		{"type:**main.T", Name{Kind: KindType, Package: "main", Receiver: "T", PtrReceiver: true}},
+		{"type:main.Pair[int,string]", Name{Kind: KindType, Package: "main", Receiver: "Pair", TypeArgs: []string{"int", "string"}}},
+		{"type:gopkg.in/yaml%2ev3.Node", Name{Kind: KindType, Package: "gopkg.in/yaml.v3", Receiver: "Node"}},
+		{"type:int", Name{Kind: KindType, Func: "int"}},
+		{"type:[]string", Name{Kind: KindType, Func: "[]string"}},
+		{"type:[]net/http.Header", Name{Kind: KindType, Func: "[]net/http.Header"}},
+		{"type:map[string]int", Name{Kind: KindType, Func: "map[string]int"}},
+		{"type:func(int) error", Name{Kind: KindType, Func: "func(int) error"}},
+		{"type:struct { X int }", Name{Kind: KindType, Func: "struct { X int }"}},
+		{"type:go.shape.int", Name{Kind: KindType, Func: "go.shape.int"}},
+		{"type:.eq.main.T", Name{Kind: KindTypeEq, Package: "main", Receiver: "T"}},
+		{"type:.eq.net/http.Request", Name{Kind: KindTypeEq, Package: "net/http", Receiver: "Request"}},
+		{"type..eq.main.T", Name{Kind: KindTypeEq, Package: "main", Receiver: "T", Old: true}},
+		{"type:.hash.main.T", Name{Kind: KindTypeHash, Package: "main", Receiver: "T"}},
+		{"type..hash.main.T", Name{Kind: KindTypeHash, Package: "main", Receiver: "T", Old: true}},
+		{"type:.eq.[2]interface {}", Name{Kind: KindTypeEq, Func: "[2]interface {}"}},
+		{"type:.eq.M17K7M84", Name{Kind: KindTypeEq, Func: "M17K7M84"}},
+		{"type:.namedata.*main.T.", Name{Kind: KindTypeData, Func: "*main.T."}},
+		{"type..namedata.*main.T.", Name{Kind: KindTypeData, Func: "*main.T.", Old: true}},
+		{"type:.importpath.net/http.", Name{Kind: KindTypeData, Func: "net/http."}},
+
+		// itabs
+		{"go:itab.*os.File,io.Writer", Name{Kind: KindItab, Package: "os", Receiver: "File", PtrReceiver: true, Interface: "io.Writer"}},
+		{"go.itab.*os.File,io.Writer", Name{Kind: KindItab, Package: "os", Receiver: "File", PtrReceiver: true, Interface: "io.Writer", Old: true}},
+		{"go:itab.net/http.Header,fmt.Stringer", Name{Kind: KindItab, Package: "net/http", Receiver: "Header", Interface: "fmt.Stringer"}},
+		{"go:itab.*main.Pair[int,string],fmt.Stringer", Name{Kind: KindItab, Package: "main", Receiver: "Pair", PtrReceiver: true, TypeArgs: []string{"int", "string"}, Interface: "fmt.Stringer"}},
+		{"go:itab.syscall.Errno,error", Name{Kind: KindItab, Package: "syscall", Receiver: "Errno", Interface: "error"}},
+
+		// strings and other runtime data
+		{"go:string.*", Name{Kind: KindString, Func: "*"}},
+		{`go.string."hello"`, Name{Kind: KindString, Func: `"hello"`, Old: true}},
+		{"go:buildid", Name{Func: "go:buildid"}},
+		{"go.buildid", Name{Func: "go.buildid"}},
+		{"go:main.inittasks", Name{Func: "go:main.inittasks"}},
+		{"go:cuinfo.producer.main", Name{Func: "go:cuinfo.producer.main"}},
+		{"go.cuinfo.packagename.main", Name{Func: "go.cuinfo.packagename.main"}},
+		{"gclocals·d4dc2f11db048877dbc0f60a22b4adb3", Name{Func: "gclocals·d4dc2f11db048877dbc0f60a22b4adb3"}},
+		{"$f64.3ff0000000000000", Name{Func: "$f64.3ff0000000000000"}},
+
+		// C symbols
+		{"_cgo_init", Name{Func: "_cgo_init"}},
+		{"x_cgo_thread_start", Name{Func: "x_cgo_thread_start"}},
+		{"_rt0_amd64_linux", Name{Func: "_rt0_amd64_linux"}},
+		{"memcpy@GLIBC_2.14", Name{Func: "memcpy@GLIBC_2.14"}},
+		{"_ZN3foo3barEv", Name{Func: "_ZN3foo3barEv"}},
+	} {
+		got := Parse(tc.sym)
+		if !reflect.DeepEqual(got, tc.exp) {
+			t.Errorf("%s: expected %+v, got %+v", tc.sym, tc.exp, got)
+		}
+	}
+}
+
+const genericProg = `package main
+
+import "fmt"
+
+type List[T any] struct{ items []T }
+
+func (l *List[T]) Push(v T) { l.items = append(l.items, v) }
+
+//go:noinline
+func Map[T, U any](s []T, f func(T) U) []U {
+	var r []U
+	for _, v := range s {
+		r = append(r, f(v))
+	}
+	return r
+}
+
+func main() {
+	var l List[int]
+	l.Push(1)
+	fmt.Println(Map(l.items, func(i int) string { return fmt.Sprint(i) }))
+}
+`
+
+func TestParseBinary(t *testing.T) {
+	f, err := elf.Open(elftest.GoBinary(t, genericProg, "-gcflags=-l"))
+	if err != nil {
+		t.Skip("not an ELF platform")
+	}
+	defer f.Close()
+	syms, err := f.Symbols()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, s := range syms {
+		n := Parse(s.Name)
+		if n.Package != "main" {
+			continue
+		}
+		found[n.Kind.String()+" "+n.Receiver+" "+n.Func+" "+strings.Join(n.Closures, ".")] = true
+	}
+	for _, exp := range []string{"func  main ", "func  main func1", "func List Push ", "func  Map ", "dict  Map "} {
+		if !found[exp] {
+			t.Errorf("expected %q in %v", exp, found)
+		}
+	}
+}
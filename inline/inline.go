@@ -0,0 +1,196 @@
+// Package inline reads which functions the compiler inlined into which
+// from the DWARF info of binaries.
+package inline
+
+import (
+	"debug/dwarf"
+	"sort"
+
+	"github.com/tzneal/bincmp/internal/objfile"
+)
+
+// Function is a function of a binary and the calls the compiler inlined
+// into it.
+type Function struct {
+	Name string
+	Size int64
+	// Inlined are the inlined functions, sorted by name
+	Inlined []Call
+}
+
+// Call is a function inlined into another, with the number of calls to it
+// that were inlined and the size of the code they left behind. Calls
+// inlined into inlined code count towards the outer function too, and the
+// size of an inlined call includes what was inlined into it.
+type Call struct {
+	Callee string
+	Count  int
+	Size   int64
+}
+
+func (c Call) IsEmpty() bool {
+	return c.Count == 0
+}
+
+// ListFunctions returns the functions of a binary with code, sorted by
+// name, from the DW_TAG_inlined_subroutine entries of its DWARF info.
+// Functions of the same name, like C static functions in different files,
+// are merged. Binaries without DWARF info, like Go binaries linked with
+// -ldflags=-w, have no functions.
+func ListFunctions(filename, arch string) ([]Function, error) {
+	f, err := objfile.Open(filename, arch)
+	if objfile.IsUnknownFormat(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if f.Archive != nil {
+		return nil, nil
+	}
+	d := f.DWARF()
+	if d == nil {
+		return nil, nil
+	}
+	return dwarfFunctions(d)
+}
+
+// function collects the inlined calls of a function while reading it
+type function struct {
+	size    int64
+	inlined map[string]*Call
+}
+
+func dwarfFunctions(d *dwarf.Data) ([]Function, error) {
+	names := newNameResolver(d)
+	fns := map[string]*function{}
+
+	// the function each level of the tree being read is in, nil outside
+	// of functions
+	var stack []*function
+	r := d.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if e == nil {
+			break
+		}
+		if e.Tag == 0 {
+			// end of the children of the entry on top of the stack
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+		var cur *function
+		if len(stack) > 0 {
+			cur = stack[len(stack)-1]
+		}
+		switch {
+		case e.Tag == dwarf.TagSubprogram && cur == nil:
+			size, err := codeSize(d, e)
+			if err != nil {
+				return nil, err
+			}
+			name := names.name(e)
+			if size > 0 && name != "" {
+				fn := fns[name]
+				if fn == nil {
+					fn = &function{inlined: map[string]*Call{}}
+					fns[name] = fn
+				}
+				fn.size += size
+				cur = fn
+			}
+		case e.Tag == dwarf.TagInlinedSubroutine && cur != nil:
+			size, err := codeSize(d, e)
+			if err != nil {
+				return nil, err
+			}
+			callee := names.name(e)
+			call := cur.inlined[callee]
+			if call == nil {
+				call = &Call{Callee: callee}
+				cur.inlined[callee] = call
+			}
+			call.Count++
+			call.Size += size
+		}
+		if e.Children {
+			stack = append(stack, cur)
+		}
+	}
+
+	ret := make([]Function, 0, len(fns))
+	for name, fn := range fns {
+		f := Function{Name: name, Size: fn.size}
+		for _, call := range fn.inlined {
+			f.Inlined = append(f.Inlined, *call)
+		}
+		sort.Slice(f.Inlined, func(i, j int) bool {
+			return f.Inlined[i].Callee < f.Inlined[j].Callee
+		})
+		ret = append(ret, f)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret, nil
+}
+
+// codeSize returns the size of the code of a function or inlined call.
+func codeSize(d *dwarf.Data, e *dwarf.Entry) (int64, error) {
+	ranges, err := d.Ranges(e)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, r := range ranges {
+		size += int64(r[1] - r[0])
+	}
+	return size, nil
+}
+
+// nameResolver finds the names of functions and inlined calls, which refer
+// to the abstract function holding the name, and C++ member functions
+// defined outside of their class, which refer to their declaration.
+type nameResolver struct {
+	d     *dwarf.Data
+	names map[dwarf.Offset]string
+}
+
+func newNameResolver(d *dwarf.Data) *nameResolver {
+	return &nameResolver{d: d, names: map[dwarf.Offset]string{}}
+}
+
+func (n *nameResolver) name(e *dwarf.Entry) string {
+	if name, ok := e.Val(dwarf.AttrName).(string); ok {
+		return name
+	}
+	for _, attr := range []dwarf.Attr{dwarf.AttrAbstractOrigin, dwarf.AttrSpecification} {
+		if off, ok := e.Val(attr).(dwarf.Offset); ok {
+			return n.nameAt(off)
+		}
+	}
+	return ""
+}
+
+// nameAt returns the name of the entry at off.
+func (n *nameResolver) nameAt(off dwarf.Offset) string {
+	if name, ok := n.names[off]; ok {
+		return name
+	}
+	// entries referring to themselves end up with no name
+	n.names[off] = ""
+	r := n.d.Reader()
+	r.Seek(off)
+	var name string
+	if e, err := r.Next(); err == nil && e != nil {
+		name = n.name(e)
+	}
+	n.names[off] = name
+	return name
+}
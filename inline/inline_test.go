@@ -0,0 +1,72 @@
+package inline
+
+import (
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+const prog = `package main
+
+import (
+	"os"
+	"strings"
+)
+
+func main() {
+	if strings.HasPrefix(os.Args[0], "/") || strings.HasPrefix(os.Args[0], ".") {
+		os.Exit(1)
+	}
+}
+`
+
+func mainCall(t *testing.T, filename, callee string) Call {
+	t.Helper()
+	fns, err := ListFunctions(filename, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	for _, fn := range fns {
+		if fn.Name != "main.main" {
+			continue
+		}
+		if fn.Size == 0 {
+			t.Errorf("expected main.main to have code")
+		}
+		for _, call := range fn.Inlined {
+			if call.Callee == callee {
+				return call
+			}
+		}
+		return Call{}
+	}
+	t.Fatalf("expected to find main.main")
+	return Call{}
+}
+
+func TestListFunctions(t *testing.T) {
+	call := mainCall(t, elftest.GoBinary(t, prog), "strings.HasPrefix")
+	if call.Count != 2 {
+		t.Errorf("expected strings.HasPrefix to be inlined twice, got %d", call.Count)
+	}
+	if call.Size == 0 {
+		t.Errorf("expected inlined strings.HasPrefix to have code")
+	}
+}
+
+func TestListFunctionsNoInlining(t *testing.T) {
+	call := mainCall(t, elftest.GoBinary(t, prog, "-gcflags=-l"), "strings.HasPrefix")
+	if !call.IsEmpty() {
+		t.Errorf("expected strings.HasPrefix not to be inlined, got %+v", call)
+	}
+}
+
+func TestListFunctionsNoDWARF(t *testing.T) {
+	fns, err := ListFunctions(elftest.GoBinary(t, prog, "-ldflags=-w"), "")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(fns) != 0 {
+		t.Errorf("expected no functions without DWARF, got %d", len(fns))
+	}
+}
@@ -0,0 +1,65 @@
+package elftest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// GoBinary compiles the single file program src with the go tool and
+// returns the path of the binary. The test is skipped if the go tool is not
+// available or in short mode, and fails if the program doesn't build.
+// Tests build their own programs, rather than reading the test binary, to
+// know what the binary contains and to pick the flags, GOOS and GOARCH it
+// is built with.
+func GoBinary(t *testing.T, src string, buildArgs ...string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping build in short mode")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go not installed")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	exe := filepath.Join(dir, "hello")
+	args := append([]string{"build", "-o", exe}, buildArgs...)
+	cmd := exec.Command(goBin, args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("building test binary: %s\n%s", err, out)
+	}
+	return exe
+}
+
+// CBinary compiles the single file C program src with cc and returns the
+// path of the output, e.g. a shared library when -shared is passed in args.
+// The test is skipped if there is no C compiler or in short mode.
+func CBinary(t *testing.T, src string, args ...string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping build in short mode")
+	}
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("cc not installed")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.c"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	cmd := exec.Command(cc, append([]string{"-o", out, "main.c"}, args...)...)
+	cmd.Dir = dir
+	if msg, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("building test binary: %s\n%s", err, msg)
+	}
+	return out
+}
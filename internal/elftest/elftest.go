@@ -0,0 +1,204 @@
+// Package elftest builds small in-memory ELF files for use in tests.
+package elftest
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+)
+
+// Section describes a section to write into the test file.
+type Section struct {
+	Name    string
+	Type    elf.SectionType
+	Flags   elf.SectionFlag
+	Addr    uint64
+	Data    []byte
+	Size    uint64 // used when Data is nil, e.g. for SHT_NOBITS sections
+	Offset  uint64 // file offset to report instead of the real one, if set
+	Link    uint32
+	Info    uint32
+	Align   uint64
+	EntSize uint64
+}
+
+// Symbol describes an entry of the symbol table.
+type Symbol struct {
+	Name    string
+	Value   uint64
+	Size    uint64
+	Bind    elf.SymBind
+	Type    elf.SymType
+	Other   uint8
+	Section elf.SectionIndex
+}
+
+// File is a 64-bit little endian ELF file under construction.
+type File struct {
+	Type     elf.Type
+	Machine  elf.Machine
+	Sections []Section
+	Symbols  []Symbol
+}
+
+// AddSection appends a section and returns its section header index.
+func (f *File) AddSection(s Section) elf.SectionIndex {
+	f.Sections = append(f.Sections, s)
+	return elf.SectionIndex(len(f.Sections))
+}
+
+// Open returns the file parsed by debug/elf.
+func (f *File) Open() (*elf.File, error) {
+	return elf.NewFile(bytes.NewReader(f.Bytes()))
+}
+
+// Bytes serializes the file. A .symtab and .strtab are added if the file
+// has symbols, and a .shstrtab is always added last.
+func (f *File) Bytes() []byte {
+	sects := append([]Section{}, f.Sections...)
+	if len(f.Symbols) > 0 {
+		symtab, strtab, nlocal := SymbolTable(f.Symbols)
+		sects = append(sects,
+			Section{Name: ".symtab", Type: elf.SHT_SYMTAB, Data: symtab,
+				Link: uint32(len(sects) + 2), Info: nlocal, Align: 8,
+				EntSize: elf.Sym64Size},
+			Section{Name: ".strtab", Type: elf.SHT_STRTAB, Data: strtab, Align: 1})
+	}
+	shstrtab := NewStringTable()
+	names := make([]uint32, len(sects)+1)
+	for i, s := range sects {
+		names[i] = shstrtab.Add(s.Name)
+	}
+	names[len(sects)] = shstrtab.Add(".shstrtab")
+	sects = append(sects, Section{Name: ".shstrtab", Type: elf.SHT_STRTAB,
+		Data: shstrtab.Bytes(), Align: 1})
+
+	var data bytes.Buffer
+	offsets := make([]uint64, len(sects))
+	const hdrSize = 64
+	for i, s := range sects {
+		for (hdrSize+data.Len())%8 != 0 {
+			data.WriteByte(0)
+		}
+		offsets[i] = uint64(hdrSize + data.Len())
+		data.Write(s.Data)
+	}
+	for (hdrSize+data.Len())%8 != 0 {
+		data.WriteByte(0)
+	}
+	shoff := uint64(hdrSize + data.Len())
+
+	typ := f.Type
+	if typ == elf.ET_NONE {
+		typ = elf.ET_EXEC
+	}
+	mach := f.Machine
+	if mach == elf.EM_NONE {
+		mach = elf.EM_X86_64
+	}
+	hdr := elf.Header64{
+		Type:      uint16(typ),
+		Machine:   uint16(mach),
+		Version:   uint32(elf.EV_CURRENT),
+		Shoff:     shoff,
+		Ehsize:    hdrSize,
+		Shentsize: 64,
+		Shnum:     uint16(len(sects) + 1),
+		Shstrndx:  uint16(len(sects)),
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, hdr)
+	out.Write(data.Bytes())
+	binary.Write(&out, binary.LittleEndian, elf.Section64{})
+	for i, s := range sects {
+		size := uint64(len(s.Data))
+		if s.Data == nil {
+			size = s.Size
+		}
+		off := offsets[i]
+		if s.Offset != 0 {
+			off = s.Offset
+		}
+		binary.Write(&out, binary.LittleEndian, elf.Section64{
+			Name:      names[i],
+			Type:      uint32(s.Type),
+			Flags:     uint64(s.Flags),
+			Addr:      s.Addr,
+			Off:       off,
+			Size:      size,
+			Link:      s.Link,
+			Info:      s.Info,
+			Addralign: s.Align,
+			Entsize:   s.EntSize,
+		})
+	}
+	return out.Bytes()
+}
+
+// SymbolTable encodes syms as the contents of a symbol table section and its
+// string table. Local symbols are moved to the front as the ELF spec
+// requires, and the number of local entries (the sh_info of the table) is
+// returned as well.
+func SymbolTable(syms []Symbol) (symtab, strtab []byte, nlocal uint32) {
+	strs := NewStringTable()
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, elf.Sym64{})
+	nlocal = 1
+	write := func(s Symbol) {
+		binary.Write(&buf, binary.LittleEndian, elf.Sym64{
+			Name:  strs.Add(s.Name),
+			Info:  elf.ST_INFO(s.Bind, s.Type),
+			Other: s.Other,
+			Shndx: uint16(s.Section),
+			Value: s.Value,
+			Size:  s.Size,
+		})
+	}
+	for _, s := range syms {
+		if s.Bind == elf.STB_LOCAL {
+			write(s)
+			nlocal++
+		}
+	}
+	for _, s := range syms {
+		if s.Bind != elf.STB_LOCAL {
+			write(s)
+		}
+	}
+	return buf.Bytes(), strs.Bytes(), nlocal
+}
+
+// StringTable accumulates a NUL separated ELF string table.
+type StringTable struct {
+	buf  bytes.Buffer
+	offs map[string]uint32
+}
+
+// NewStringTable returns a string table holding only the empty string.
+func NewStringTable() *StringTable {
+	t := &StringTable{offs: map[string]uint32{"": 0}}
+	t.buf.WriteByte(0)
+	return t
+}
+
+// Add returns the offset of s, adding it to the table if needed.
+func (t *StringTable) Add(s string) uint32 {
+	if off, ok := t.offs[s]; ok {
+		return off
+	}
+	off := uint32(t.buf.Len())
+	t.buf.WriteString(s)
+	t.buf.WriteByte(0)
+	t.offs[s] = off
+	return off
+}
+
+// Bytes returns the encoded table.
+func (t *StringTable) Bytes() []byte {
+	return t.buf.Bytes()
+}
@@ -0,0 +1,247 @@
+// Package objfile opens binaries in any of the object file formats bincmp
+// understands.
+package objfile
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/tzneal/bincmp/ar"
+	"github.com/tzneal/bincmp/wasm"
+)
+
+// File is an opened binary. Exactly one of the format specific fields is
+// set. Archive members can be opened with OpenMember.
+type File struct {
+	ELF     *elf.File
+	MachO   *macho.File
+	PE      *pe.File
+	Wasm    *wasm.Module
+	Archive []ar.Member
+
+	r      io.ReaderAt
+	closer io.Closer
+}
+
+var errUnknownFormat = errors.New("unrecognized object file format")
+
+// IsUnknownFormat reports whether the error was caused by a file that isn't
+// in a supported format.
+func IsUnknownFormat(e error) bool {
+	return errors.Is(e, errUnknownFormat)
+}
+
+// Open opens the named binary. If it is a Mach-O universal binary, the
+// slice for arch is selected.
+func Open(name, arch string) (*File, error) {
+	r, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := NewFile(r, arch)
+	if err != nil {
+		r.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	f.closer = r
+	return f, nil
+}
+
+// NewFile detects the format of the binary in r and parses it. If it is a
+// Mach-O universal binary, the slice for arch is selected.
+func NewFile(r io.ReaderAt, arch string) (*File, error) {
+	if ar.IsArchive(r) {
+		members, err := ar.List(r)
+		if err != nil {
+			return nil, err
+		}
+		return &File{Archive: members, r: r}, nil
+	}
+
+	var magic [4]byte
+	if _, err := r.ReadAt(magic[:], 0); err != nil {
+		return nil, errUnknownFormat
+	}
+	le := binary.LittleEndian.Uint32(magic[:])
+	be := binary.BigEndian.Uint32(magic[:])
+	switch {
+	case string(magic[:]) == wasm.Magic:
+		m, err := wasm.NewModule(r)
+		if err != nil {
+			return nil, err
+		}
+		return &File{Wasm: m}, nil
+	case string(magic[:2]) == "MZ" || isCOFF(binary.LittleEndian.Uint16(magic[:])):
+		f, err := pe.NewFile(r)
+		if err != nil {
+			return nil, err
+		}
+		return &File{PE: f}, nil
+	case string(magic[:]) == elf.ELFMAG:
+		f, err := elf.NewFile(r)
+		if err != nil {
+			return nil, err
+		}
+		return &File{ELF: f}, nil
+	case le == macho.Magic32 || le == macho.Magic64 ||
+		be == macho.Magic32 || be == macho.Magic64:
+		f, err := macho.NewFile(r)
+		if err != nil {
+			return nil, err
+		}
+		return &File{MachO: f}, nil
+	case be == macho.MagicFat:
+		ff, err := macho.NewFatFile(r)
+		if err != nil {
+			return nil, err
+		}
+		f, err := selectArch(ff, arch)
+		if err != nil {
+			return nil, err
+		}
+		return &File{MachO: f}, nil
+	}
+	return nil, errUnknownFormat
+}
+
+// OpenMember parses a member of an archive. Members that aren't object files,
+// such as Go export data, fail with an unknown format error.
+func (f *File) OpenMember(m ar.Member, arch string) (*File, error) {
+	return NewFile(f.MemberReader(m), arch)
+}
+
+// MemberReader returns the raw data of an archive member.
+func (f *File) MemberReader(m ar.Member) *io.SectionReader {
+	return ar.Open(f.r, m)
+}
+
+// DWARF returns the DWARF info of f, or nil if it has none, as in binaries
+// linked with -ldflags=-w.
+func (f *File) DWARF() *dwarf.Data {
+	var d *dwarf.Data
+	var err error
+	switch {
+	case f.ELF != nil:
+		d, err = f.ELF.DWARF()
+	case f.MachO != nil:
+		d, err = f.MachO.DWARF()
+	case f.PE != nil:
+		d, err = f.PE.DWARF()
+	}
+	if err != nil {
+		return nil
+	}
+	return d
+}
+
+// GOARCH returns the Go name of the architecture of f's code, like "amd64"
+// or "arm64", or "" for architectures other than x86 and arm.
+func (f *File) GOARCH() string {
+	switch {
+	case f.ELF != nil:
+		switch f.ELF.Machine {
+		case elf.EM_386:
+			return "386"
+		case elf.EM_X86_64:
+			return "amd64"
+		case elf.EM_ARM:
+			return "arm"
+		case elf.EM_AARCH64:
+			return "arm64"
+		}
+	case f.MachO != nil:
+		switch f.MachO.Cpu {
+		case macho.Cpu386:
+			return "386"
+		case macho.CpuAmd64:
+			return "amd64"
+		case macho.CpuArm:
+			return "arm"
+		case macho.CpuArm64:
+			return "arm64"
+		}
+	case f.PE != nil:
+		switch f.PE.Machine {
+		case pe.IMAGE_FILE_MACHINE_I386:
+			return "386"
+		case pe.IMAGE_FILE_MACHINE_AMD64:
+			return "amd64"
+		case pe.IMAGE_FILE_MACHINE_ARMNT:
+			return "arm"
+		case pe.IMAGE_FILE_MACHINE_ARM64:
+			return "arm64"
+		}
+	}
+	return ""
+}
+
+// isCOFF reports whether machine is the machine type of a COFF object file
+// we expect to find, as these don't have a magic number of their own.
+func isCOFF(machine uint16) bool {
+	switch machine {
+	case pe.IMAGE_FILE_MACHINE_I386, pe.IMAGE_FILE_MACHINE_AMD64,
+		pe.IMAGE_FILE_MACHINE_ARMNT, pe.IMAGE_FILE_MACHINE_ARM64:
+		return true
+	}
+	return false
+}
+
+// Close closes the underlying file if it was opened by Open.
+func (f *File) Close() error {
+	if f.closer != nil {
+		return f.closer.Close()
+	}
+	return nil
+}
+
+// selectArch picks a slice out of a universal binary. Without an explicit
+// arch, the slice matching the host is used, or the only slice if there is
+// just one.
+func selectArch(ff *macho.FatFile, arch string) (*macho.File, error) {
+	if arch == "" {
+		if len(ff.Arches) == 1 {
+			return ff.Arches[0].File, nil
+		}
+		arch = runtime.GOARCH
+	}
+	var names []string
+	for _, a := range ff.Arches {
+		for _, n := range archNames(a.Cpu) {
+			if n == arch {
+				return a.File, nil
+			}
+		}
+		names = append(names, archNames(a.Cpu)[0])
+	}
+	return nil, fmt.Errorf("universal binary has no %s slice, it contains %s",
+		arch, strings.Join(names, ", "))
+}
+
+// archNames returns the names a Mach-O CPU type can be selected by, in both
+// the Go and Apple spelling.
+func archNames(cpu macho.Cpu) []string {
+	switch cpu {
+	case macho.Cpu386:
+		return []string{"i386", "386"}
+	case macho.CpuAmd64:
+		return []string{"x86_64", "amd64"}
+	case macho.CpuArm:
+		return []string{"arm"}
+	case macho.CpuArm64:
+		return []string{"arm64"}
+	case macho.CpuPpc:
+		return []string{"ppc"}
+	case macho.CpuPpc64:
+		return []string{"ppc64"}
+	}
+	return []string{cpu.String()}
+}
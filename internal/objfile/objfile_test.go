@@ -0,0 +1,100 @@
+package objfile
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+const prog = "package main\n\nfunc main() {}\n"
+
+func darwinBinary(t *testing.T, arch string) []byte {
+	t.Helper()
+	t.Setenv("GOOS", "darwin")
+	t.Setenv("GOARCH", arch)
+	t.Setenv("CGO_ENABLED", "0")
+	buf, err := os.ReadFile(elftest.GoBinary(t, prog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+// fatBinary joins thin Mach-O files into a universal binary as lipo does.
+func fatBinary(t *testing.T, slices ...[]byte) []byte {
+	t.Helper()
+	const align = 14
+	var hdr, body bytes.Buffer
+	binary.Write(&hdr, binary.BigEndian, [2]uint32{macho.MagicFat, uint32(len(slices))})
+	off := uint32(1 << align)
+	for _, s := range slices {
+		f, err := macho.NewFile(bytes.NewReader(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		binary.Write(&hdr, binary.BigEndian, macho.FatArchHeader{
+			Cpu: f.Cpu, SubCpu: f.SubCpu, Offset: off, Size: uint32(len(s)), Align: align})
+		body.Write(make([]byte, int(off)-(1<<align)-body.Len()))
+		body.Write(s)
+		off += (uint32(len(s)) + 1<<align - 1) &^ (1<<align - 1)
+	}
+	hdr.Write(make([]byte, 1<<align-hdr.Len()))
+	return append(hdr.Bytes(), body.Bytes()...)
+}
+
+func TestNewFileFat(t *testing.T) {
+	fat := fatBinary(t, darwinBinary(t, "amd64"), darwinBinary(t, "arm64"))
+
+	for arch, cpu := range map[string]macho.Cpu{
+		"amd64":  macho.CpuAmd64,
+		"x86_64": macho.CpuAmd64,
+		"arm64":  macho.CpuArm64,
+	} {
+		f, err := NewFile(bytes.NewReader(fat), arch)
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err)
+		}
+		if f.MachO == nil {
+			t.Fatalf("expected a Mach-O file for %s", arch)
+		}
+		if f.MachO.Cpu != cpu {
+			t.Errorf("expected %s for %s, got %s", cpu, arch, f.MachO.Cpu)
+		}
+	}
+
+	if _, err := NewFile(bytes.NewReader(fat), "ppc64"); err == nil {
+		t.Errorf("expected an error for a missing slice")
+	}
+}
+
+func TestNewFileUnknownFormat(t *testing.T) {
+	_, err := NewFile(bytes.NewReader([]byte("#!/bin/sh\nexit 0\n")), "")
+	if !IsUnknownFormat(err) {
+		t.Errorf("expected unknown format error, got %v", err)
+	}
+}
+
+func TestGOARCH(t *testing.T) {
+	for _, tc := range []struct{ goos, goarch string }{
+		{"linux", "386"},
+		{"linux", "arm"},
+		{"darwin", "arm64"},
+		{"windows", "amd64"},
+	} {
+		t.Setenv("GOOS", tc.goos)
+		t.Setenv("GOARCH", tc.goarch)
+		t.Setenv("CGO_ENABLED", "0")
+		f, err := Open(elftest.GoBinary(t, prog), "")
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err)
+		}
+		if got := f.GOARCH(); got != tc.goarch {
+			t.Errorf("%s/%s: expected %q, got %q", tc.goos, tc.goarch, tc.goarch, got)
+		}
+		f.Close()
+	}
+}
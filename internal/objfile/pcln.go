@@ -0,0 +1,98 @@
+package objfile
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+)
+
+var errNoPcln = errors.New("no Go pclntab found")
+
+// IsNoPcln reports whether the error was caused by a binary without a Go
+// pclntab, i.e. one that wasn't built by the Go toolchain.
+func IsNoPcln(e error) bool {
+	return errors.Is(e, errNoPcln)
+}
+
+// Pcln returns the Go pclntab of the binary along with the start address
+// of the text it describes, for use with debug/gosym. The pclntab survives
+// stripping with -ldflags=-s, so this works when the symbol table doesn't.
+func (f *File) Pcln() (textStart uint64, pclntab []byte, err error) {
+	switch {
+	case f.ELF != nil:
+		if sect := f.ELF.Section(".text"); sect != nil {
+			textStart = sect.Addr
+		}
+		sect := f.ELF.Section(".gopclntab")
+		if sect == nil {
+			// PIE binaries
+			sect = f.ELF.Section(".data.rel.ro.gopclntab")
+		}
+		if sect != nil {
+			pclntab, err = sect.Data()
+		}
+	case f.MachO != nil:
+		if sect := f.MachO.Section("__text"); sect != nil {
+			textStart = sect.Addr
+		}
+		if sect := f.MachO.Section("__gopclntab"); sect != nil {
+			pclntab, err = sect.Data()
+		}
+	case f.PE != nil:
+		// PE has no pclntab section and the runtime.pclntab symbol is gone
+		// in stripped binaries, so look for the table header in .rdata
+		var base uint64
+		switch oh := f.PE.OptionalHeader.(type) {
+		case *pe.OptionalHeader32:
+			base = uint64(oh.ImageBase)
+		case *pe.OptionalHeader64:
+			base = oh.ImageBase
+		}
+		if sect := f.PE.Section(".text"); sect != nil {
+			textStart = base + uint64(sect.VirtualAddress)
+		}
+		if sect := f.PE.Section(".rdata"); sect != nil {
+			var data []byte
+			if data, err = sect.Data(); err == nil {
+				pclntab = findPcln(data)
+			}
+		}
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(pclntab) == 0 {
+		return 0, nil, errNoPcln
+	}
+	return textStart, pclntab, nil
+}
+
+// pclnMagics are the magic numbers of the pclntab formats of Go 1.2, 1.16,
+// 1.18 and 1.20.
+var pclnMagics = []uint32{0xfffffffb, 0xfffffffa, 0xfffffff0, 0xfffffff1}
+
+// findPcln searches data for something that looks like a pclntab header and
+// returns the data from there on. debug/gosym stops at the end of the table
+// so trailing data doesn't matter.
+func findPcln(data []byte) []byte {
+	for _, magic := range pclnMagics {
+		var m [4]byte
+		binary.LittleEndian.PutUint32(m[:], magic)
+		for off := 0; ; {
+			i := bytes.Index(data[off:], m[:])
+			if i < 0 {
+				break
+			}
+			hdr := data[off+i:]
+			// magic, two zero pad bytes, instruction size quantum, pointer size
+			if len(hdr) >= 8 && hdr[4] == 0 && hdr[5] == 0 &&
+				(hdr[6] == 1 || hdr[6] == 2 || hdr[6] == 4) &&
+				(hdr[7] == 4 || hdr[7] == 8) {
+				return hdr
+			}
+			off += i + 1
+		}
+	}
+	return nil
+}
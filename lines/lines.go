@@ -0,0 +1,240 @@
+// Package lines attributes the code of binaries to the source lines it was
+// compiled from.
+package lines
+
+import (
+	"debug/buildinfo"
+	"debug/dwarf"
+	"errors"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tzneal/bincmp/internal/objfile"
+)
+
+// Line is a line of a source file and the number of bytes of code
+// generated for it.
+type Line struct {
+	File string
+	Line int
+	Size int64
+}
+
+// ListLines returns the size of the code of every source line of a binary,
+// sorted by file and line, read from its DWARF line tables. Go binaries
+// linked without DWARF, with -ldflags=-w, are read from their pclntab
+// instead. Inlined code counts towards the line it was written on, not the
+// line it was inlined into. Paths in GOROOT, the Go module cache and the
+// directory of the main module are trimmed the way -trimpath does, so that
+// binaries built on different machines or from different checkouts compare.
+// Other binaries without DWARF info have no lines.
+func ListLines(filename, arch string) ([]Line, error) {
+	f, err := objfile.Open(filename, arch)
+	if objfile.IsUnknownFormat(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if f.Archive != nil {
+		return nil, nil
+	}
+	var sizes map[pos]int64
+	var mainFile string
+	if d := f.DWARF(); d != nil {
+		sizes, err = dwarfLines(d)
+		if err == nil {
+			mainFile, err = dwarfMainFile(d)
+		}
+	} else {
+		var textStart uint64
+		var pclntab []byte
+		textStart, pclntab, err = f.Pcln()
+		if objfile.IsNoPcln(err) {
+			return nil, nil
+		}
+		if err == nil {
+			sizes, mainFile, err = pclnLines(pclntab, textStart)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	modDir, modPath := mainModule(filename, mainFile)
+	return trimPaths(sizes, modDir, modPath), nil
+}
+
+// pos is a position in a source file
+type pos struct {
+	file string
+	line int
+}
+
+// dwarfLines sums the sizes of the rows of the line tables of d, each
+// running up to the address of the next row of its sequence.
+func dwarfLines(d *dwarf.Data) (map[pos]int64, error) {
+	ret := map[pos]int64{}
+	r := d.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if e == nil {
+			break
+		}
+		if e.Tag != dwarf.TagCompileUnit {
+			r.SkipChildren()
+			continue
+		}
+		lr, err := d.LineReader(e)
+		if err != nil {
+			return nil, err
+		}
+		r.SkipChildren()
+		if lr == nil {
+			continue
+		}
+		var prev *dwarf.LineEntry
+		for {
+			var le dwarf.LineEntry
+			err := lr.Next(&le)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if prev != nil && prev.File != nil && le.Address > prev.Address {
+				ret[pos{prev.File.Name, prev.Line}] += int64(le.Address - prev.Address)
+			}
+			prev = &le
+			if le.EndSequence {
+				prev = nil
+			}
+		}
+	}
+	return ret, nil
+}
+
+// dwarfMainFile returns the file the main.main function of a Go binary is
+// declared in, or "" if it has none. Go names the compile unit of each
+// package by its import path, and that of the main package "main".
+func dwarfMainFile(d *dwarf.Data) (string, error) {
+	r := d.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return "", err
+		}
+		if e == nil {
+			return "", nil
+		}
+		if e.Tag != dwarf.TagCompileUnit || e.Val(dwarf.AttrName) != "main" {
+			r.SkipChildren()
+			continue
+		}
+		lr, err := d.LineReader(e)
+		if err != nil || lr == nil {
+			return "", err
+		}
+		for {
+			c, err := r.Next()
+			if err != nil {
+				return "", err
+			}
+			if c == nil || c.Tag == 0 {
+				break
+			}
+			r.SkipChildren()
+			if c.Tag != dwarf.TagSubprogram || c.Val(dwarf.AttrName) != "main.main" {
+				continue
+			}
+			files := lr.Files()
+			if i, ok := c.Val(dwarf.AttrDeclFile).(int64); ok && i >= 0 && i < int64(len(files)) && files[i] != nil {
+				return files[i].Name, nil
+			}
+		}
+	}
+}
+
+// mainModule returns the directory the main module of a Go binary was
+// built in and its module path, from the file of the main function and the
+// import path of the main package in the build info. The main package of
+// "example.com/m/cmd/app" built from /src/m/cmd/app/main.go puts the module
+// in /src/m. It returns "" for binaries without build info.
+func mainModule(filename, mainFile string) (dir, modPath string) {
+	if mainFile == "" {
+		return "", ""
+	}
+	bi, err := buildinfo.ReadFile(filename)
+	if err != nil || bi.Main.Path == "" || !strings.HasPrefix(bi.Path, bi.Main.Path) {
+		return "", ""
+	}
+	rel := bi.Path[len(bi.Main.Path):]
+	pkgDir := path.Dir(mainFile)
+	if rel != "" && rel[0] != '/' || !strings.HasSuffix(pkgDir, rel) {
+		return "", ""
+	}
+	return strings.TrimSuffix(pkgDir, rel), bi.Main.Path
+}
+
+// trimPaths returns the lines of sizes with the GOROOT of the binary and
+// the module cache trimmed from their paths, as in "runtime/proc.go" and
+// "golang.org/x/net@v0.1.0/http2/frame.go", and the directory modDir of
+// the main module replaced by its path modPath.
+func trimPaths(sizes map[pos]int64, modDir, modPath string) []Line {
+	// GOROOT is wherever the runtime is
+	goroot := ""
+	for p := range sizes {
+		if i := strings.Index(p.file, "/src/runtime/"); i >= 0 && !strings.Contains(p.file, "/pkg/mod/") {
+			goroot = p.file[:i+len("/src/")]
+			break
+		}
+	}
+	trimmed := map[pos]int64{}
+	for p, size := range sizes {
+		if goroot != "" && strings.HasPrefix(p.file, goroot) {
+			p.file = p.file[len(goroot):]
+		} else if modDir != "" && strings.HasPrefix(p.file, modDir+"/") {
+			p.file = modPath + p.file[len(modDir):]
+		} else if i := strings.Index(p.file, "/pkg/mod/"); i >= 0 {
+			p.file = unescapeModPath(p.file[i+len("/pkg/mod/"):])
+		}
+		trimmed[p] += size
+	}
+
+	ret := make([]Line, 0, len(trimmed))
+	for p, size := range trimmed {
+		ret = append(ret, Line{File: p.file, Line: p.line, Size: size})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].File != ret[j].File {
+			return ret[i].File < ret[j].File
+		}
+		return ret[i].Line < ret[j].Line
+	})
+	return ret
+}
+
+// unescapeModPath undoes the escaping of upper case letters in the paths of
+// the module cache, "github.com/!burnt!sushi" for "github.com/BurntSushi".
+func unescapeModPath(path string) string {
+	if !strings.Contains(path, "!") {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '!' && i+1 < len(path) {
+			i++
+			b.WriteRune(unicode.ToUpper(rune(path[i])))
+			continue
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
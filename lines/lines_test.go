@@ -0,0 +1,109 @@
+package lines
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+const inlineProg = `package main
+
+import (
+	"os"
+	"strings"
+)
+
+func main() {
+	if strings.HasPrefix(os.Args[0], "/") {
+		os.Exit(1)
+	}
+}
+`
+
+func TestListLines(t *testing.T) {
+	ls, err := ListLines(elftest.GoBinary(t, inlineProg), "")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	files := map[string]int64{}
+	for _, l := range ls {
+		files[l.File] += l.Size
+	}
+	// strings.HasPrefix is inlined into main
+	for _, f := range []string{"strings/strings.go", "runtime/proc.go"} {
+		if files[f] == 0 {
+			t.Errorf("expected code from %s", f)
+		}
+	}
+	// the main module is trimmed to its module path
+	if files["example.com/hello/main.go"] == 0 {
+		t.Errorf("expected code from example.com/hello/main.go, got %v", files)
+	}
+}
+
+func TestTrimPaths(t *testing.T) {
+	sizes := map[pos]int64{
+		{"/usr/local/go/src/runtime/proc.go", 10}:                                1,
+		{"/usr/local/go/src/fmt/print.go", 20}:                                   2,
+		{"/home/u/go/pkg/mod/github.com/!burnt!sushi/toml@v1.2.0/decode.go", 30}: 3,
+		{"/home/u/go/pkg/mod/golang.org/x/net@v0.1.0/http2/frame.go", 40}:        4,
+		{"/home/u/src/app/main.go", 50}:                                          5,
+		{"example.com/app/internal/util.go", 60}:                                 6,
+	}
+	exp := []Line{
+		{File: "/home/u/src/app/main.go", Line: 50, Size: 5},
+		{File: "example.com/app/internal/util.go", Line: 60, Size: 6},
+		{File: "fmt/print.go", Line: 20, Size: 2},
+		{File: "github.com/BurntSushi/toml@v1.2.0/decode.go", Line: 30, Size: 3},
+		{File: "golang.org/x/net@v0.1.0/http2/frame.go", Line: 40, Size: 4},
+		{File: "runtime/proc.go", Line: 10, Size: 1},
+	}
+	got := trimPaths(sizes, "", "")
+	if len(got) != len(exp) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+	for i := range exp {
+		if got[i] != exp[i] {
+			t.Errorf("expected %v, got %v", exp[i], got[i])
+		}
+	}
+}
+
+func TestTrimPathsMainModule(t *testing.T) {
+	sizes := map[pos]int64{
+		{"/usr/local/go/src/runtime/proc.go", 10}:                         1,
+		{"/home/u/src/app/cmd/app/main.go", 20}:                           2,
+		{"/home/u/src/app/internal/db/db.go", 30}:                         3,
+		{"/home/u/src/app2/main.go", 40}:                                  4,
+		{"/home/u/go/pkg/mod/golang.org/x/net@v0.1.0/http2/frame.go", 50}: 5,
+	}
+	exp := []Line{
+		{File: "/home/u/src/app2/main.go", Line: 40, Size: 4},
+		{File: "example.com/app/cmd/app/main.go", Line: 20, Size: 2},
+		{File: "example.com/app/internal/db/db.go", Line: 30, Size: 3},
+		{File: "golang.org/x/net@v0.1.0/http2/frame.go", Line: 50, Size: 5},
+		{File: "runtime/proc.go", Line: 10, Size: 1},
+	}
+	got := trimPaths(sizes, "/home/u/src/app", "example.com/app")
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+}
+
+func TestListLinesMainModule(t *testing.T) {
+	// the same module built in two directories has the same files
+	for _, args := range [][]string{nil, {"-ldflags=-w"}} {
+		a := fileSizes(t, elftest.GoBinary(t, inlineProg, args...))
+		b := fileSizes(t, elftest.GoBinary(t, inlineProg, args...))
+		if a["example.com/hello/main.go"] == 0 || a["example.com/hello/main.go"] != b["example.com/hello/main.go"] {
+			t.Errorf("%v: expected example.com/hello/main.go in both builds, got %v and %v", args, a, b)
+		}
+		for f := range b {
+			if strings.HasPrefix(f, "/") {
+				t.Errorf("%v: expected no absolute paths, got %s", args, f)
+			}
+		}
+	}
+}
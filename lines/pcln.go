@@ -0,0 +1,312 @@
+package lines
+
+import (
+	"bytes"
+	"debug/gosym"
+	"encoding/binary"
+	"errors"
+)
+
+// pclntab magic numbers of the formats pclnLines decodes
+const (
+	pcln116 = 0xfffffffa
+	pcln118 = 0xfffffff0
+	pcln120 = 0xfffffff1
+)
+
+var errPclnFormat = errors.New("malformed pclntab")
+
+// pcln is a Go 1.16 or later pclntab, as laid out by the linker in
+// cmd/link/internal/ld/pcln.go.
+type pcln struct {
+	order     binary.ByteOrder
+	version   uint32
+	quantum   uint64
+	ptrSize   int
+	nfunc     int
+	textStart uint64
+	funcnames []byte
+	cutab     []byte
+	filetab   []byte
+	pctab     []byte
+	functab   []byte
+}
+
+// pclnLines sums the sizes of the code of each line of the functions of a
+// Go pclntab, and returns the file of the main.main function. The pcfile
+// and pcln tables of each function map its code to the file and line it
+// was written on, inlined code included. Tables older than Go 1.16 are
+// looked up one address at a time with debug/gosym, which is much slower.
+func pclnLines(data []byte, textStart uint64) (map[pos]int64, string, error) {
+	t, ok := newPcln(data)
+	if !ok {
+		return gosymLines(data, textStart)
+	}
+	ret := map[pos]int64{}
+	var mainFile string
+	for i := 0; i < t.nfunc; i++ {
+		entry, off, err := t.function(i)
+		if err != nil {
+			return nil, "", err
+		}
+		// the entry after the last function holds the end of the text
+		end, _, err := t.function(i + 1)
+		if err != nil {
+			return nil, "", err
+		}
+		if off > uint64(len(t.functab)) {
+			return nil, "", errPclnFormat
+		}
+		fn := t.functab[off:]
+		if err := t.addLines(ret, fn, entry, end); err != nil {
+			return nil, "", err
+		}
+		if mainFile == "" && t.funcName(fn) == "main.main" {
+			mainFile = t.entryFile(fn, entry)
+		}
+	}
+	return ret, mainFile, nil
+}
+
+// newPcln parses the header of a pclntab, reporting false if it isn't in a
+// format it knows.
+func newPcln(data []byte) (*pcln, bool) {
+	if len(data) < 8 {
+		return nil, false
+	}
+	t := &pcln{order: binary.LittleEndian}
+	t.version = t.order.Uint32(data)
+	if t.version != pcln116 && t.version != pcln118 && t.version != pcln120 {
+		t.order = binary.BigEndian
+		t.version = t.order.Uint32(data)
+	}
+	if t.version != pcln116 && t.version != pcln118 && t.version != pcln120 {
+		return nil, false
+	}
+	t.quantum = uint64(data[6])
+	t.ptrSize = int(data[7])
+	if t.ptrSize != 4 && t.ptrSize != 8 {
+		return nil, false
+	}
+
+	// the header words, 1.18 added the start of the text
+	nwords := 7
+	if t.version != pcln116 {
+		nwords = 8
+	}
+	if len(data) < 8+nwords*t.ptrSize {
+		return nil, false
+	}
+	words := make([]uint64, nwords)
+	for i := range words {
+		words[i] = t.uintptr(data[8+i*t.ptrSize:])
+	}
+	t.nfunc = int(words[0])
+	if t.version != pcln116 {
+		t.textStart = words[2]
+		words = append(words[:2], words[3:]...)
+	}
+	section := func(off uint64) []byte {
+		if off > uint64(len(data)) {
+			return nil
+		}
+		return data[off:]
+	}
+	t.funcnames = section(words[2])
+	t.cutab = section(words[3])
+	t.filetab = section(words[4])
+	t.pctab = section(words[5])
+	t.functab = section(words[6])
+	return t, t.functab != nil && t.pctab != nil
+}
+
+func (t *pcln) uintptr(b []byte) uint64 {
+	if t.ptrSize == 4 {
+		return uint64(t.order.Uint32(b))
+	}
+	return t.order.Uint64(b)
+}
+
+// function returns the entry address of the i'th function and the offset
+// of its _func struct in the functab.
+func (t *pcln) function(i int) (uint64, uint64, error) {
+	// pairs of entry and _func offsets, of 32 bits since 1.18
+	size := 2 * t.ptrSize
+	if t.version != pcln116 {
+		size = 8
+	}
+	if (i+1)*size > len(t.functab) {
+		return 0, 0, errPclnFormat
+	}
+	ent := t.functab[i*size:]
+	var entry, off uint64
+	if t.version == pcln116 {
+		entry, off = t.uintptr(ent), t.uintptr(ent[t.ptrSize:])
+	} else {
+		entry, off = t.textStart+uint64(t.order.Uint32(ent)), uint64(t.order.Uint32(ent[4:]))
+	}
+	return entry, off, nil
+}
+
+// fields returns the fields of the _func struct fn after the entry:
+// nameOff, args, deferreturn, pcsp, pcfile, pcln, npcdata and cuOffset.
+func (t *pcln) fields(fn []byte) ([8]uint32, bool) {
+	var ret [8]uint32
+	off := 4
+	if t.version == pcln116 {
+		off = t.ptrSize
+	}
+	if len(fn) < off+4*len(ret) {
+		return ret, false
+	}
+	for i := range ret {
+		ret[i] = t.order.Uint32(fn[off+4*i:])
+	}
+	return ret, true
+}
+
+// funcName returns the name of the function fn.
+func (t *pcln) funcName(fn []byte) string {
+	f, ok := t.fields(fn)
+	if !ok || uint64(f[0]) >= uint64(len(t.funcnames)) {
+		return ""
+	}
+	name := t.funcnames[f[0]:]
+	if end := bytes.IndexByte(name, 0); end >= 0 {
+		name = name[:end]
+	}
+	return string(name)
+}
+
+// entryFile returns the file of the first instruction of the function fn
+// at entry.
+func (t *pcln) entryFile(fn []byte, entry uint64) string {
+	f, ok := t.fields(fn)
+	if !ok || f[4] == 0 {
+		return ""
+	}
+	files := t.pcvalues(f[4], entry)
+	if len(files) == 0 {
+		return ""
+	}
+	return t.fileName(f[7], files[0].val)
+}
+
+// addLines adds the sizes of the code of the lines of the function fn,
+// from entry to end, to sizes.
+func (t *pcln) addLines(sizes map[pos]int64, fn []byte, entry, end uint64) error {
+	f, ok := t.fields(fn)
+	if !ok {
+		return errPclnFormat
+	}
+	pcfile, pcln, cuOffset := f[4], f[5], f[7]
+	if pcfile == 0 || pcln == 0 {
+		// assembly functions without line information
+		return nil
+	}
+	files := t.pcvalues(pcfile, entry)
+	lines := t.pcvalues(pcln, entry)
+
+	// both tables split the code into runs, walk them together
+	fi, li := 0, 0
+	for pc := entry; pc < end && fi < len(files) && li < len(lines); {
+		next := end
+		if files[fi].end < next {
+			next = files[fi].end
+		}
+		if lines[li].end < next {
+			next = lines[li].end
+		}
+		if next > pc {
+			sizes[pos{t.fileName(cuOffset, files[fi].val), int(lines[li].val)}] += int64(next - pc)
+			pc = next
+		}
+		if files[fi].end <= pc {
+			fi++
+		}
+		if lines[li].end <= pc {
+			li++
+		}
+	}
+	return nil
+}
+
+// run is a value of a pcvalue table, from the end of the previous run up
+// to end.
+type run struct {
+	end uint64
+	val int32
+}
+
+// pcvalues decodes the pcvalue table at off of the function at entry. Each
+// step is a zigzag encoded value delta followed by a pc delta in units of
+// the instruction quantum, both as varints, starting from the value -1.
+func (t *pcln) pcvalues(off uint32, entry uint64) []run {
+	if uint64(off) >= uint64(len(t.pctab)) {
+		return nil
+	}
+	p := t.pctab[off:]
+	var ret []run
+	val := int32(-1)
+	pc := entry
+	for first := true; ; first = false {
+		uvdelta, n := binary.Uvarint(p)
+		if n <= 0 || uvdelta == 0 && !first {
+			break
+		}
+		p = p[n:]
+		if uvdelta&1 != 0 {
+			uvdelta = ^(uvdelta >> 1)
+		} else {
+			uvdelta >>= 1
+		}
+		val += int32(uvdelta)
+		pcdelta, n := binary.Uvarint(p)
+		if n <= 0 {
+			break
+		}
+		p = p[n:]
+		pc += pcdelta * t.quantum
+		ret = append(ret, run{end: pc, val: val})
+	}
+	return ret
+}
+
+// fileName returns the name of the file with index fileno in the
+// compilation unit at cuOffset.
+func (t *pcln) fileName(cuOffset uint32, fileno int32) string {
+	i := 4 * (uint64(cuOffset) + uint64(fileno))
+	if fileno < 0 || i+4 > uint64(len(t.cutab)) {
+		return "?"
+	}
+	off := t.order.Uint32(t.cutab[i:])
+	if uint64(off) >= uint64(len(t.filetab)) {
+		return "?"
+	}
+	name := t.filetab[off:]
+	if end := bytes.IndexByte(name, 0); end >= 0 {
+		name = name[:end]
+	}
+	return string(name)
+}
+
+// gosymLines looks up the line of every address of the functions of an old
+// pclntab.
+func gosymLines(data []byte, textStart uint64) (map[pos]int64, string, error) {
+	tab, err := gosym.NewTable(nil, gosym.NewLineTable(data, textStart))
+	if err != nil {
+		return nil, "", err
+	}
+	ret := map[pos]int64{}
+	for _, fn := range tab.Funcs {
+		for pc := fn.Entry; pc < fn.End; pc++ {
+			file, line, _ := tab.PCToLine(pc)
+			ret[pos{file, line}]++
+		}
+	}
+	var mainFile string
+	if fn := tab.LookupFunc("main.main"); fn != nil {
+		mainFile, _, _ = tab.PCToLine(fn.Entry)
+	}
+	return ret, mainFile, nil
+}
@@ -0,0 +1,49 @@
+package lines
+
+import (
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+// fileSizes sums the code of each file of exe.
+func fileSizes(t *testing.T, exe string) map[string]int64 {
+	t.Helper()
+	ls, err := ListLines(exe, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	ret := map[string]int64{}
+	for _, l := range ls {
+		ret[l.File] += l.Size
+	}
+	return ret
+}
+
+func TestListLinesPcln(t *testing.T) {
+	dwarf := fileSizes(t, elftest.GoBinary(t, inlineProg))
+	pcln := fileSizes(t, elftest.GoBinary(t, inlineProg, "-ldflags=-w"))
+	if len(pcln) == 0 {
+		t.Fatalf("expected lines from the pclntab")
+	}
+	for file, size := range dwarf {
+		// DWARF leaves out some compiler generated wrappers
+		if file == "<autogenerated>" {
+			continue
+		}
+		if pcln[file] != size {
+			t.Errorf("expected %d bytes of %s, got %d", size, file, pcln[file])
+		}
+	}
+}
+
+func TestListLinesPcln32(t *testing.T) {
+	t.Setenv("GOARCH", "386")
+	t.Setenv("CGO_ENABLED", "0")
+	files := fileSizes(t, elftest.GoBinary(t, inlineProg, "-ldflags=-w"))
+	for _, f := range []string{"example.com/hello/main.go", "strings/strings.go", "runtime/proc.go"} {
+		if files[f] == 0 {
+			t.Errorf("expected code from %s", f)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package nm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/tzneal/bincmp/ar"
+	"github.com/tzneal/bincmp/internal/objfile"
+)
+
+// goobjMagic starts the header line of the Go object files found in
+// archives built with -buildmode=archive
+const goobjMagic = "go object "
+
+// archiveSymbols lists the symbols of every object file in an archive, with
+// Member set to the name of the member defining them. Go object files
+// can't be read natively, so their symbols are taken from "go tool nm" if
+// the go tool is installed.
+func archiveSymbols(filename string, f *objfile.File, arch string) ([]Symbol, error) {
+	ret := []Symbol{}
+	var goMembers []string
+	for _, m := range f.Archive {
+		mf, err := f.OpenMember(m, arch)
+		if objfile.IsUnknownFormat(err) {
+			if isGoObject(f, m) {
+				goMembers = append(goMembers, m.Name)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s(%s): %w", filename, m.Name, err)
+		}
+		syms, err := symbolTable(mf)
+		if err != nil {
+			return nil, fmt.Errorf("%s(%s): %w", filename, m.Name, err)
+		}
+		for _, s := range syms {
+			s.Member = m.Name
+			ret = append(ret, s)
+		}
+	}
+
+	if len(goMembers) > 0 && haveGo() {
+		syms, err := listGoToolSymbols(filename, goMembers)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, syms...)
+	}
+	return ret, nil
+}
+
+func isGoObject(f *objfile.File, m ar.Member) bool {
+	// export data starts with the same header
+	if m.Name == "__.PKGDEF" {
+		return false
+	}
+	var magic [len(goobjMagic)]byte
+	_, err := io.ReadFull(f.MemberReader(m), magic[:])
+	return err == nil && string(magic[:]) == goobjMagic
+}
+
+func haveGo() bool {
+	_, err := exec.LookPath("go")
+	return err == nil
+}
+
+// listGoToolSymbols runs "go tool nm" on an archive holding Go object
+// files. Its output is only prefixed with the member name if the archive
+// has several objects, otherwise the symbols belong to the only one.
+func listGoToolSymbols(filename string, members []string) ([]Symbol, error) {
+	args := []string{"tool", "nm", "-size", "-sort", "none", filename}
+	out, err := exec.Command("go", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running go %s: %s", args, err)
+	}
+	member := members[0]
+	return parseGoToolSymbols(bytes.NewReader(out), filename, member)
+}
+
+func parseGoToolSymbols(r io.Reader, filename, member string) ([]Symbol, error) {
+	scanner := bufio.NewScanner(r)
+	ret := []Symbol{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		m := member
+		// "file(member):\t" prefix
+		if strings.HasPrefix(line, filename+"(") {
+			if i := strings.Index(line, "):\t"); i >= 0 {
+				m = line[len(filename)+1 : i]
+				line = line[i+3:]
+			}
+		}
+		// format is "address size type name", without an address for
+		// undefined symbols
+		fields := strings.Fields(line)
+		if len(fields) < 4 || len(fields[2]) != 1 {
+			continue
+		}
+		value, err := strconv.ParseInt(fields[0], 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse value %s", fields[0])
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse size %s", fields[1])
+		}
+		ret = append(ret, Symbol{
+			Name:    strings.Join(fields[3:], " "),
+			Type:    decodeType(fields[2]),
+			Kind:    decodeKind(fields[2]),
+			Size:    size,
+			Value:   value,
+			Binding: decodeBinding(fields[2]),
+			Member:  m,
+		})
+	}
+	return ret, scanner.Err()
+}
@@ -0,0 +1,83 @@
+package nm
+
+import (
+	"debug/elf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tzneal/bincmp/ar"
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+func object(syms ...string) []byte {
+	f := elftest.File{Type: elf.ET_REL}
+	text := f.AddSection(elftest.Section{Name: ".text", Type: elf.SHT_PROGBITS,
+		Flags: elf.SHF_ALLOC | elf.SHF_EXECINSTR, Data: make([]byte, 64)})
+	for i, s := range syms {
+		f.Symbols = append(f.Symbols, elftest.Symbol{Name: s, Value: uint64(i * 16),
+			Size: 16, Bind: elf.STB_GLOBAL, Type: elf.STT_FUNC, Section: text})
+	}
+	return f.Bytes()
+}
+
+func TestListSymbolsArchive(t *testing.T) {
+	archive := ar.Magic
+	for _, m := range []struct {
+		name string
+		data []byte
+	}{
+		{"a.o", object("foo", "bar")},
+		{"b.o", object("foo")},
+		{"README", []byte("not an object\n")},
+	} {
+		archive += fmt.Sprintf("%-16s%-12d%-6d%-6d%-8o%-10d`\n", m.name+"/", 0, 0, 0, 0644, len(m.data))
+		archive += string(m.data)
+		if len(m.data)%2 != 0 {
+			archive += "\n"
+		}
+	}
+	fn := filepath.Join(t.TempDir(), "lib.a")
+	if err := os.WriteFile(fn, []byte(archive), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	syms, err := ListSymbols(fn)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	var got []string
+	for _, s := range syms {
+		got = append(got, s.Member+":"+s.Name)
+	}
+	if exp := "a.o:bar a.o:foo b.o:foo"; strings.Join(got, " ") != exp {
+		t.Errorf("expected %s, got %s", exp, strings.Join(got, " "))
+	}
+}
+
+func TestParseGoToolSymbols(t *testing.T) {
+	inp := `lib.a(_go_.o):	                  0 U 
+lib.a(_go_.o):	     bab          4 T example.com/lib.Add
+lib.a(other.o):	     be9         24 d example.com/lib..stmp_0<1>
+     d09          5 r example.com/lib.Add.arginfo1<1>
+`
+	syms, err := parseGoToolSymbols(strings.NewReader(inp), "lib.a", "_go_.o")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	exp := []Symbol{
+		{Name: "example.com/lib.Add", Type: SymbolTypeGlobalText, Kind: KindText, Size: 4, Value: 0xbab, Binding: BindingGlobal, Member: "_go_.o"},
+		{Name: "example.com/lib..stmp_0<1>", Type: SymbolTypeData, Kind: KindData, Size: 24, Value: 0xbe9, Binding: BindingLocal, Member: "other.o"},
+		{Name: "example.com/lib.Add.arginfo1<1>", Type: SymbolTypeReadOnlyData, Kind: KindReadOnlyData, Size: 5, Value: 0xd09, Binding: BindingLocal, Member: "_go_.o"},
+	}
+	if len(syms) != len(exp) {
+		t.Fatalf("expected %d syms, got %v", len(exp), syms)
+	}
+	for i := range exp {
+		if syms[i] != exp[i] {
+			t.Errorf("expected %v, got %v", exp[i], syms[i])
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package nm
+
+import (
+	"crypto/sha256"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/tzneal/bincmp/internal/objfile"
+)
+
+// HashContents sets the Hash of the symbols in syms, as listed from
+// filename, that have contents in the file. Bytes that relocations patch are
+// zeroed before hashing, so that a function or table that only moved, or
+// refers to symbols that moved, keeps its hash. Object files and shared
+// libraries record these relocations; linked executables only keep the
+// dynamic ones, so their code still hashes differently once PC-relative
+// call or data references change. The data of linked executables is hashed
+// with the pointers it holds, the aligned words that point into one of the
+// sections, replaced by the symbol they point into. WebAssembly modules and
+// Go object files aren't hashed.
+func HashContents(filename, arch string, syms []Symbol) error {
+	f, err := objfile.Open(filename, arch)
+	if objfile.IsUnknownFormat(err) {
+		// the symbols came from nm and have no section
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if f.Archive == nil {
+		c := newContents(f)
+		c.ptrs = newPointers(f, syms)
+		for i := range syms {
+			syms[i].Hash = c.hash(syms[i])
+		}
+		return nil
+	}
+
+	byMember := map[string][]int{}
+	for i, s := range syms {
+		byMember[s.Member] = append(byMember[s.Member], i)
+	}
+	for _, m := range f.Archive {
+		idx := byMember[m.Name]
+		if len(idx) == 0 {
+			continue
+		}
+		mf, err := f.OpenMember(m, arch)
+		if err != nil {
+			// Go object files can't be read
+			continue
+		}
+		c := newContents(mf)
+		for _, i := range idx {
+			syms[i].Hash = c.hash(syms[i])
+		}
+	}
+	return nil
+}
+
+// span is a range of bytes, relative to the start of a section
+type span struct {
+	off, len int64
+}
+
+// sectionData is the contents of a section, with the ranges relocations
+// patch
+type sectionData struct {
+	data   []byte
+	addr   int64
+	relocs []span
+}
+
+// contents reads the data of the sections of an object file as symbols
+// need it.
+type contents struct {
+	f        *objfile.File
+	sections map[int]*sectionData
+	// elfRelocs are the relocations of an ELF file by section index
+	elfRelocs map[int][]span
+	// ptrs resolves the pointers in the data of linked executables
+	ptrs *pointers
+}
+
+func newContents(f *objfile.File) *contents {
+	c := &contents{f: f, sections: map[int]*sectionData{}}
+	if f.ELF != nil {
+		c.elfRelocs = elfRelocations(f.ELF)
+	}
+	return c
+}
+
+// hash returns the hash of the masked contents of s, or "" if it has none.
+func (c *contents) hash(s Symbol) string {
+	if s.Section == 0 || s.Size <= 0 {
+		return ""
+	}
+	sect := c.section(s.Section)
+	if sect == nil {
+		return ""
+	}
+	start := s.Value - sect.addr
+	end := start + s.Size
+	if start < 0 || end > int64(len(sect.data)) {
+		return ""
+	}
+	buf := make([]byte, s.Size)
+	copy(buf, sect.data[start:end])
+	// relocs are sorted, skip to the first one that may overlap
+	i := sort.Search(len(sect.relocs), func(i int) bool {
+		return sect.relocs[i].off+sect.relocs[i].len > start
+	})
+	for ; i < len(sect.relocs) && sect.relocs[i].off < end; i++ {
+		r := sect.relocs[i]
+		for off := r.off; off < r.off+r.len; off++ {
+			if off >= start && off < end {
+				buf[off-start] = 0
+			}
+		}
+	}
+	if c.ptrs != nil && s.Kind != KindText {
+		buf = c.ptrs.resolve(buf, s.Value)
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+// addrRange is the address range of a loaded section
+type addrRange struct {
+	addr, size int64
+	idx        int
+}
+
+// pointers finds the pointers in the data of a linked executable, whose
+// addresses change whenever the code or data they point to moves.
+type pointers struct {
+	size  int64
+	order binary.ByteOrder
+	sects []addrRange
+	// syms are the defined symbols by address, the largest first
+	syms []Symbol
+}
+
+// newPointers returns the pointers of f, whose symbols are syms, or nil if
+// f isn't a linked executable or shared library.
+func newPointers(f *objfile.File, syms []Symbol) *pointers {
+	p := &pointers{size: 8}
+	switch {
+	case f.ELF != nil && f.ELF.Type != elf.ET_REL:
+		if f.ELF.Class != elf.ELFCLASS64 {
+			p.size = 4
+		}
+		p.order = f.ELF.ByteOrder
+		for i, s := range f.ELF.Sections {
+			if s.Flags&elf.SHF_ALLOC != 0 && s.Addr != 0 {
+				p.sects = append(p.sects, addrRange{int64(s.Addr), int64(s.Size), i})
+			}
+		}
+	case f.MachO != nil && f.MachO.Type != macho.TypeObj:
+		if f.MachO.Magic == macho.Magic32 {
+			p.size = 4
+		}
+		p.order = f.MachO.ByteOrder
+		for i, s := range f.MachO.Sections {
+			p.sects = append(p.sects, addrRange{int64(s.Addr), int64(s.Size), i + 1})
+		}
+	case f.PE != nil && f.PE.OptionalHeader != nil:
+		if _, ok := f.PE.OptionalHeader.(*pe.OptionalHeader32); ok {
+			p.size = 4
+		}
+		p.order = binary.LittleEndian
+		base := peImageBase(f.PE)
+		for i, s := range f.PE.Sections {
+			p.sects = append(p.sects, addrRange{base + int64(s.VirtualAddress), int64(s.VirtualSize), i + 1})
+		}
+	default:
+		return nil
+	}
+	for _, s := range syms {
+		if s.Section != 0 && s.Member == "" && s.Kind != KindAbsolute && s.Kind != KindDebug {
+			p.syms = append(p.syms, s)
+		}
+	}
+	sort.Slice(p.syms, func(i, j int) bool {
+		a, b := p.syms[i], p.syms[j]
+		if a.Value != b.Value {
+			return a.Value < b.Value
+		}
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		return a.Name < b.Name
+	})
+	return p
+}
+
+// resolve returns data, found at addr, with each aligned word that points
+// into a section replaced by the name of the symbol it points into, or of
+// the section if there is none. Symbols without a size, like go:string.*,
+// extend to the next symbol in their section. Offsets are left out, as
+// they change whenever the data before them in a symbol changes size.
+func (p *pointers) resolve(data []byte, addr int64) []byte {
+	var ret []byte
+	for i := int64(0); i < int64(len(data)); {
+		if (addr+i)%p.size == 0 && i+p.size <= int64(len(data)) {
+			var v int64
+			if p.size == 4 {
+				v = int64(p.order.Uint32(data[i:]))
+			} else {
+				v = int64(p.order.Uint64(data[i:]))
+			}
+			if name, ok := p.lookup(v); ok {
+				ret = append(ret, 0)
+				ret = append(ret, name...)
+				ret = append(ret, 0)
+				i += p.size
+				continue
+			}
+		}
+		ret = append(ret, data[i])
+		i++
+	}
+	return ret
+}
+
+// lookup returns the name of the symbol or section containing addr, or
+// ending at it.
+func (p *pointers) lookup(addr int64) (string, bool) {
+	var sect *addrRange
+	for i := range p.sects {
+		if addr >= p.sects[i].addr && addr < p.sects[i].addr+p.sects[i].size {
+			sect = &p.sects[i]
+			break
+		}
+		// the end of a section, like runtime.epclntab
+		if addr == p.sects[i].addr+p.sects[i].size {
+			sect = &p.sects[i]
+		}
+	}
+	if sect == nil {
+		return "", false
+	}
+	i := sort.Search(len(p.syms), func(i int) bool {
+		return p.syms[i].Value > addr
+	}) - 1
+	// the largest of the symbols at the same address sorts first
+	for i > 0 && p.syms[i-1].Value == p.syms[i].Value {
+		i--
+	}
+	if i >= 0 {
+		s := p.syms[i]
+		if addr < s.Value+s.Size || s.Size == 0 && s.Section == sect.idx {
+			return s.Name, true
+		}
+	}
+	return fmt.Sprintf("section %d", sect.idx), true
+}
+
+// section returns the contents of a section by the index symbols use, or
+// nil if the section has no contents in the file.
+func (c *contents) section(idx int) *sectionData {
+	if sect, ok := c.sections[idx]; ok {
+		return sect
+	}
+	var sect *sectionData
+	switch {
+	case c.f.ELF != nil:
+		sect = elfSectionData(c.f.ELF, idx, c.elfRelocs[idx])
+	case c.f.MachO != nil:
+		sect = machoSectionData(c.f.MachO, idx)
+	case c.f.PE != nil:
+		sect = peSectionData(c.f.PE, idx)
+	}
+	if sect != nil {
+		sort.Slice(sect.relocs, func(i, j int) bool {
+			return sect.relocs[i].off < sect.relocs[j].off
+		})
+	}
+	c.sections[idx] = sect
+	return sect
+}
+
+func elfSectionData(f *elf.File, idx int, relocs []span) *sectionData {
+	if idx >= len(f.Sections) {
+		return nil
+	}
+	s := f.Sections[idx]
+	if s.Type == elf.SHT_NOBITS {
+		return nil
+	}
+	data, err := s.Data()
+	if err != nil {
+		return nil
+	}
+	// symbols of relocatable objects are relative to their section
+	addr := int64(s.Addr)
+	if f.Type == elf.ET_REL {
+		addr = 0
+	}
+	return &sectionData{data: data, addr: addr, relocs: relocs}
+}
+
+// elfRelocations returns the bytes patched by the relocations of f, by the
+// index of the section they apply to. Relocation sections of object files
+// name the section they apply to, while the dynamic relocations of linked
+// files apply to addresses.
+func elfRelocations(f *elf.File) map[int][]span {
+	ret := map[int][]span{}
+	is64 := f.Class == elf.ELFCLASS64
+	for _, s := range f.Sections {
+		if s.Type != elf.SHT_RELA && s.Type != elf.SHT_REL {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			continue
+		}
+		entSize := 8
+		switch {
+		case is64 && s.Type == elf.SHT_RELA:
+			entSize = 24
+		case is64:
+			entSize = 16
+		case s.Type == elf.SHT_RELA:
+			entSize = 12
+		}
+		for off := 0; off+entSize <= len(data); off += entSize {
+			var roff uint64
+			var typ uint32
+			if is64 {
+				roff = f.ByteOrder.Uint64(data[off:])
+				typ = uint32(f.ByteOrder.Uint64(data[off+8:]))
+			} else {
+				roff = uint64(f.ByteOrder.Uint32(data[off:]))
+				typ = f.ByteOrder.Uint32(data[off+4:]) & 0xff
+			}
+			width := elfRelocWidth(f, typ)
+			if f.Type == elf.ET_REL {
+				ret[int(s.Info)] = append(ret[int(s.Info)], span{off: int64(roff), len: width})
+				continue
+			}
+			for i, t := range f.Sections {
+				if t.Type != elf.SHT_NOBITS && t.Addr != 0 && roff >= t.Addr && roff < t.Addr+t.Size {
+					ret[i] = append(ret[i], span{off: int64(roff - t.Addr), len: width})
+					break
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// elfRelocWidth returns the number of bytes a relocation type patches.
+// Relocations of instructions patch at most the 4 bytes of an instruction
+// or displacement, while dynamic relocations of linked files patch
+// pointers.
+func elfRelocWidth(f *elf.File, typ uint32) int64 {
+	if f.Class != elf.ELFCLASS64 {
+		return 4
+	}
+	if f.Type != elf.ET_REL {
+		return 8
+	}
+	switch f.Machine {
+	case elf.EM_X86_64:
+		switch elf.R_X86_64(typ) {
+		case elf.R_X86_64_64, elf.R_X86_64_PC64, elf.R_X86_64_GOTOFF64,
+			elf.R_X86_64_DTPOFF64, elf.R_X86_64_TPOFF64:
+			return 8
+		}
+	case elf.EM_AARCH64:
+		switch elf.R_AARCH64(typ) {
+		case elf.R_AARCH64_ABS64, elf.R_AARCH64_PREL64:
+			return 8
+		}
+	}
+	return 4
+}
+
+func machoSectionData(f *macho.File, idx int) *sectionData {
+	if idx < 1 || idx > len(f.Sections) {
+		return nil
+	}
+	s := f.Sections[idx-1]
+	if machoIsZerofill(s) {
+		return nil
+	}
+	data, err := s.Data()
+	if err != nil {
+		return nil
+	}
+	sect := &sectionData{data: data, addr: int64(s.Addr)}
+	for _, r := range s.Relocs {
+		if r.Scattered {
+			continue
+		}
+		sect.relocs = append(sect.relocs, span{off: int64(r.Addr), len: 1 << r.Len})
+	}
+	return sect
+}
+
+func peSectionData(f *pe.File, idx int) *sectionData {
+	if idx < 1 || idx > len(f.Sections) {
+		return nil
+	}
+	s := f.Sections[idx-1]
+	if s.Characteristics&pe.IMAGE_SCN_CNT_UNINITIALIZED_DATA != 0 {
+		return nil
+	}
+	data, err := s.Data()
+	if err != nil {
+		return nil
+	}
+	sect := &sectionData{data: data, addr: peImageBase(f) + int64(s.VirtualAddress)}
+	for _, r := range s.Relocs {
+		width := int64(4)
+		if f.Machine == pe.IMAGE_FILE_MACHINE_AMD64 && r.Type == peRelAMD64Addr64 ||
+			f.Machine == pe.IMAGE_FILE_MACHINE_ARM64 && r.Type == peRelARM64Addr64 {
+			width = 8
+		}
+		sect.relocs = append(sect.relocs, span{off: int64(r.VirtualAddress), len: width})
+	}
+	return sect
+}
@@ -0,0 +1,119 @@
+package nm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+const contentsSrc = `
+int x, y;
+int a(void) { return x * 3 + 7; }
+int b(void) { return y * 3 + 7; }
+int c(void) { return x * 5 + 7; }
+int d[4] = {1, 2, 3, 4};
+int e[4] = {1, 2, 3, 4};
+`
+
+func TestHashContents(t *testing.T) {
+	obj := elftest.CBinary(t, contentsSrc, "-c", "-O1", "-fno-pic")
+	syms, err := ListSymbols(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := HashContents(obj, "", syms); err != nil {
+		t.Fatal(err)
+	}
+	hashes := map[string]string{}
+	for _, s := range syms {
+		hashes[s.Name] = s.Hash
+	}
+
+	// a and b only differ in the variable they load, which is relocated
+	for _, tc := range []struct {
+		a, b string
+		same bool
+	}{
+		{"a", "b", true},
+		{"a", "c", false},
+		{"d", "e", true},
+		{"a", "d", false},
+	} {
+		if hashes[tc.a] == "" || hashes[tc.b] == "" {
+			t.Fatalf("expected hashes for %s and %s, got %v", tc.a, tc.b, hashes)
+		}
+		if same := hashes[tc.a] == hashes[tc.b]; same != tc.same {
+			t.Errorf("expected same hash of %s and %s to be %v, got %v", tc.a, tc.b, tc.same, same)
+		}
+	}
+	// x and y are in .bss
+	if hashes["x"] != "" {
+		t.Errorf("expected no hash for bss symbol x, got %s", hashes["x"])
+	}
+}
+
+const pointersProg = `package main
+
+import "os"
+
+type entry struct {
+	name string
+	n    int
+	f    func() int
+}
+
+func zero() {
+	%s
+}
+
+func one() int { return 1 }
+func two() int { return 2 }
+
+var table = [...]entry{{"one", 1, one}, {"two", 2, two}}
+var limits = [...]int{%d, 2, 3}
+
+func main() {
+	zero()
+	for _, e := range table {
+		if e.f() == len(os.Args) {
+			os.Exit(e.n + limits[e.n])
+		}
+	}
+}
+`
+
+func TestHashContentsPointers(t *testing.T) {
+	hashes := func(code string, limit int) map[string]string {
+		exe := elftest.GoBinary(t, fmt.Sprintf(pointersProg, code, limit), "-gcflags=-l")
+		syms, err := ListSymbols(exe)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := HashContents(exe, "", syms); err != nil {
+			t.Fatal(err)
+		}
+		ret := map[string]string{}
+		for _, s := range syms {
+			ret[s.Name] = s.Hash
+		}
+		return ret
+	}
+	a := hashes("", 1)
+	// more code moves the functions table points to
+	b := hashes(`println("moved", len(os.Args))`, 4)
+	for _, tc := range []struct {
+		name string
+		same bool
+	}{
+		{"main.table", true},
+		{"main.limits", false},
+	} {
+		if a[tc.name] == "" {
+			t.Fatalf("expected a hash for %s", tc.name)
+		}
+		if same := a[tc.name] == b[tc.name]; same != tc.same {
+			t.Errorf("expected same hash of %s to be %v, got %v", tc.name, tc.same, same)
+		}
+	}
+}
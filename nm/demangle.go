@@ -0,0 +1,179 @@
+package nm
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+func haveCxxfilt() bool {
+	_, err := exec.LookPath("c++filt")
+	return err == nil
+}
+
+// mangledName returns the Itanium C++ or Rust mangled name in a symbol name,
+// without the extra underscore Mach-O prefixes every name with, or "" if the
+// name isn't mangled.
+func mangledName(name string) string {
+	if strings.HasPrefix(name, "__Z") || strings.HasPrefix(name, "__R") {
+		name = name[1:]
+	}
+	if !strings.HasPrefix(name, "_Z") && !strings.HasPrefix(name, "_R") ||
+		strings.ContainsAny(name, " \n") {
+		return ""
+	}
+	return name
+}
+
+// rustHash matches the hash legacy Rust mangling appends to every path
+var rustHash = regexp.MustCompile(`::h[0-9a-f]{16}$`)
+
+// warnNoCxxfilt prints a warning the first time mangled names are found
+// without c++filt to demangle them
+var warnNoCxxfilt sync.Once
+
+// Demangle fills in the Demangled name of C++ and Rust symbols by piping
+// their names through c++filt. Symbols are left as is, with a warning on
+// stderr, if c++filt isn't installed.
+func Demangle(syms []Symbol) error {
+	var idx []int
+	var names strings.Builder
+	for i, s := range syms {
+		name := mangledName(s.Name)
+		if name == "" {
+			continue
+		}
+		idx = append(idx, i)
+		names.WriteString(name)
+		names.WriteByte('\n')
+	}
+	if len(idx) == 0 {
+		return nil
+	}
+	if !haveCxxfilt() {
+		warnNoCxxfilt.Do(func() {
+			fmt.Fprintln(os.Stderr, "warning: c++filt not found, C++ and Rust symbols are not demangled")
+		})
+		return nil
+	}
+
+	cmd := exec.Command("c++filt")
+	cmd.Stdin = strings.NewReader(names.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("error running c++filt: %s", err)
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	scanner.Buffer(nil, 1<<20)
+	for i := 0; scanner.Scan() && i < len(idx); i++ {
+		s := &syms[idx[i]]
+		if d := scanner.Text(); d != mangledName(s.Name) {
+			s.Demangled = rustHash.ReplaceAllString(d, "")
+		}
+	}
+	return scanner.Err()
+}
+
+// cxxSpecialPrefixes are the descriptions c++filt puts in front of the
+// names of compiler generated symbols
+var cxxSpecialPrefixes = []string{
+	"construction vtable for ",
+	"covariant return thunk to ",
+	"guard variable for ",
+	"non-virtual thunk to ",
+	"reference temporary for ",
+	"transaction clone for ",
+	"typeinfo name for ",
+	"typeinfo for ",
+	"virtual thunk to ",
+	"vtable for ",
+	"VTT for ",
+	"TLS init function for ",
+	"TLS wrapper function for ",
+}
+
+// Namespace returns the outermost C++ namespace or Rust crate of a
+// demangled name, e.g. "std" for "std::vector<int>::push_back(int const&)",
+// "tokio" for "tokio::runtime::Runtime::new" or "alloc" for
+// "<alloc::vec::Vec<T> as core::ops::drop::Drop>::drop". Names in the
+// global namespace return "".
+func Namespace(demangled string) string {
+	name := demangled
+	for _, p := range cxxSpecialPrefixes {
+		if strings.HasPrefix(name, p) {
+			name = name[len(p):]
+			break
+		}
+	}
+	// for "<T as Trait>::method", T owns the method unless it has no path,
+	// as in blanket implementations for references or primitive types
+	if strings.HasPrefix(name, "<") {
+		name = name[1:]
+		if i := strings.Index(name, " as "); i >= 0 {
+			self := strings.TrimLeft(name[:i], "&*")
+			self = strings.TrimPrefix(strings.TrimPrefix(self, "mut "), "const ")
+			if ns := Namespace(self); ns != "" {
+				return ns
+			}
+			name = name[i+len(" as "):]
+		}
+	}
+	name = qualifiedName(name)
+	depth := 0
+	for i := 0; i < len(name); i++ {
+		switch name[i] {
+		case '<', '(', '[':
+			depth++
+		case '>', ')', ']':
+			depth--
+		case ':':
+			if depth == 0 && strings.HasPrefix(name[i:], "::") {
+				return stripDisambiguator(name[:i])
+			}
+		}
+	}
+	return ""
+}
+
+// qualifiedName strips the parameter list and, for template functions, the
+// return type of a demangled function name.
+func qualifiedName(name string) string {
+	depth, start := 0, 0
+	for i := 0; i < len(name); i++ {
+		switch name[i] {
+		case '<', '[':
+			depth++
+		case '>', ']':
+			depth--
+		case '(':
+			// "(anonymous namespace)" is part of the name
+			if strings.HasPrefix(name[i:], "(anonymous namespace)") {
+				i += len("(anonymous namespace)") - 1
+				continue
+			}
+			if depth == 0 {
+				return name[start:i]
+			}
+		case ' ':
+			// "operator<" and friends aren't templates, but don't matter
+			// here as they can only follow the namespace
+			if depth == 0 {
+				start = i + 1
+			}
+		}
+	}
+	return name[start:]
+}
+
+// stripDisambiguator removes the crate hash c++filt shows for Rust v0
+// names, as in "mycrate[3c1c0]".
+func stripDisambiguator(crate string) string {
+	if i := strings.IndexByte(crate, '['); i > 0 && strings.HasSuffix(crate, "]") {
+		return crate[:i]
+	}
+	return crate
+}
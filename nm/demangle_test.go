@@ -0,0 +1,59 @@
+package nm
+
+import "testing"
+
+func TestDemangle(t *testing.T) {
+	if !haveCxxfilt() {
+		t.Skip("c++filt not installed")
+	}
+	syms := []Symbol{
+		{Name: "_ZN3foo3barEv"},
+		{Name: "__ZNSt6vectorIiSaIiEE9push_backERKi"},
+		{Name: "_ZN5tokio7runtime4task3raw7RawTask4poll17h0123456789abcdefE"},
+		{Name: "main.main"},
+		{Name: "_Zbogus"},
+	}
+	if err := Demangle(syms); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	exp := []string{
+		"foo::bar()",
+		"std::vector<int, std::allocator<int> >::push_back(int const&)",
+		"tokio::runtime::task::raw::RawTask::poll",
+		"",
+		"",
+	}
+	for i, s := range syms {
+		if s.Demangled != exp[i] {
+			t.Errorf("expected %q, got %q", exp[i], s.Demangled)
+		}
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	for _, tc := range []struct {
+		name, exp string
+	}{
+		{"foo::bar()", "foo"},
+		{"std::vector<int, std::allocator<int> >::push_back(int const&)", "std"},
+		{"void std::__1::sort<int*>(int*, int*)", "std"},
+		{"std::map<int, int, std::less<int>, std::allocator<std::pair<int const, int> > >::~map()", "std"},
+		{"vtable for absl::Status", "absl"},
+		{"typeinfo name for google::protobuf::Message", "google"},
+		{"non-virtual thunk to foo::Bar::~Bar()", "foo"},
+		{"(anonymous namespace)::helper(int)", "(anonymous namespace)"},
+		{"foo::operator<(foo::Bar const&, foo::Bar const&)", "foo"},
+		{"operator new(unsigned long)", ""},
+		{"global_function(int)", ""},
+		{"tokio::runtime::Runtime::new", "tokio"},
+		{"<alloc::vec::Vec<T> as core::ops::drop::Drop>::drop", "alloc"},
+		{"<&T as core::fmt::Debug>::fmt", "core"},
+		{"<i32 as core::fmt::Display>::fmt", "core"},
+		{"mycrate[3c1c0]::foo", "mycrate"},
+		{"core::ptr::drop_in_place<serde_json::value::Value>", "core"},
+	} {
+		if got := Namespace(tc.name); got != tc.exp {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.exp, got)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package nm
+
+import (
+	"debug/elf"
+	"errors"
+	"sort"
+)
+
+// ELFSymbols reads the symbol table of an ELF file directly, without
+// relying on an external nm binary. Section and file symbols are skipped as
+// nm does by default, and the result is sorted by name.
+func ELFSymbols(f *elf.File) ([]Symbol, error) {
+	syms, err := f.Symbols()
+	if errors.Is(err, elf.ErrNoSymbols) {
+		return []Symbol{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]Symbol, 0, len(syms))
+	for _, s := range syms {
+		switch elf.ST_TYPE(s.Info) {
+		case elf.STT_SECTION, elf.STT_FILE:
+			continue
+		}
+		bind := elfBinding(s)
+		kind := elfKind(f, s)
+		ret = append(ret, Symbol{
+			Name:    s.Name,
+			Type:    elfSymbolType(f, s, kind, bind),
+			Kind:    kind,
+			Size:    int64(s.Size),
+			Value:   int64(s.Value),
+			Section: int(s.Section),
+			Binding: bind,
+		})
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret, nil
+}
+
+// elfBinding maps the ELF symbol binding to a Binding.
+func elfBinding(s elf.Symbol) Binding {
+	switch elf.ST_BIND(s.Info) {
+	case elf.STB_LOCAL:
+		return BindingLocal
+	case elf.STB_WEAK:
+		return BindingWeak
+	case elf.STB_LOOS:
+		// STB_GNU_UNIQUE
+		return BindingUnique
+	default:
+		return BindingGlobal
+	}
+}
+
+// elfKind classifies a symbol by its section index and the flags of the
+// section it is defined in, following the rules nm uses to pick its type
+// letter.
+func elfKind(f *elf.File, s elf.Symbol) SymbolKind {
+	switch {
+	case s.Section == elf.SHN_UNDEF:
+		return KindUndefined
+	case s.Section == elf.SHN_ABS:
+		return KindAbsolute
+	case s.Section == elf.SHN_COMMON:
+		return KindCommon
+	case s.Section >= elf.SHN_LORESERVE || int(s.Section) >= len(f.Sections):
+		return KindUnknown
+	}
+	sect := f.Sections[s.Section]
+	switch {
+	case elf.ST_TYPE(s.Info) == elf.STT_TLS || sect.Flags&elf.SHF_TLS != 0:
+		return KindTLS
+	case sect.Flags&elf.SHF_ALLOC == 0:
+		return KindDebug
+	case sect.Flags&elf.SHF_EXECINSTR != 0:
+		return KindText
+	case sect.Type == elf.SHT_NOBITS:
+		return KindBSS
+	case sect.Flags&elf.SHF_WRITE != 0:
+		return KindData
+	default:
+		return KindReadOnlyData
+	}
+}
+
+// elfSymbolType picks the nm type letter of a symbol. Unlike the other
+// formats, ELF tells weak objects from weak functions and marks indirect
+// functions.
+func elfSymbolType(f *elf.File, s elf.Symbol, kind SymbolKind, bind Binding) SymbolType {
+	typ := elf.ST_TYPE(s.Info)
+	object := typ == elf.STT_OBJECT || typ == elf.STT_TLS
+	switch {
+	case bind == BindingWeak && kind == KindUndefined && object:
+		return SymbolTypeWeakObjectUndefined
+	case bind == BindingWeak && kind != KindUndefined && object:
+		return SymbolTypeWeakObject
+	case bind == BindingWeak && kind != KindUndefined:
+		return SymbolTypeWeak
+	case typ == elf.STT_LOOS && kind == KindText:
+		// STT_GNU_IFUNC
+		return SymbolTypeIndirectFunction
+	case kind == KindTLS && bind != BindingUnique &&
+		f.Sections[s.Section].Type == elf.SHT_NOBITS:
+		return pick(bind != BindingLocal, SymbolTypeBSS, SymbolTypeGlobalBSS)
+	}
+	return symbolType(kind, bind)
+}
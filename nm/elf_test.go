@@ -0,0 +1,297 @@
+package nm
+
+import (
+	"debug/elf"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+const helloProg = `package main
+
+import "fmt"
+
+func main() { fmt.Println("Hello") }
+`
+
+// fixtureELF builds an ELF file holding the symbols that nm reported in the
+// given nm -S output.
+func fixtureELF(t *testing.T, syms []Symbol) *elf.File {
+	t.Helper()
+	var f elftest.File
+	text := f.AddSection(elftest.Section{Name: ".text", Type: elf.SHT_PROGBITS,
+		Flags: elf.SHF_ALLOC | elf.SHF_EXECINSTR, Addr: 0x401000, Data: make([]byte, 16)})
+	rodata := f.AddSection(elftest.Section{Name: ".rodata", Type: elf.SHT_PROGBITS,
+		Flags: elf.SHF_ALLOC, Addr: 0x800000, Data: make([]byte, 16)})
+	data := f.AddSection(elftest.Section{Name: ".data", Type: elf.SHT_PROGBITS,
+		Flags: elf.SHF_ALLOC | elf.SHF_WRITE, Addr: 0xa00000, Data: make([]byte, 16)})
+	bss := f.AddSection(elftest.Section{Name: ".bss", Type: elf.SHT_NOBITS,
+		Flags: elf.SHF_ALLOC | elf.SHF_WRITE, Addr: 0xa90000, Size: 0x10000})
+	for _, s := range syms {
+		es := elftest.Symbol{Name: s.Name, Value: uint64(s.Value), Size: uint64(s.Size)}
+		switch s.Type {
+		case SymbolTypeText, SymbolTypeGlobalText:
+			es.Section, es.Type = text, elf.STT_FUNC
+		case SymbolTypeReadOnlyData, SymbolTypeGlobalReadOnlyData:
+			es.Section, es.Type = rodata, elf.STT_OBJECT
+		case SymbolTypeData, SymbolTypeGlobalData:
+			es.Section, es.Type = data, elf.STT_OBJECT
+		case SymbolTypeBSS, SymbolTypeGlobalBSS:
+			es.Section, es.Type = bss, elf.STT_OBJECT
+		default:
+			t.Fatalf("unhandled symbol type %s", s.Type)
+		}
+		switch s.Binding {
+		case BindingLocal:
+			es.Bind = elf.STB_LOCAL
+		case BindingWeak:
+			es.Bind = elf.STB_WEAK
+		default:
+			es.Bind = elf.STB_GLOBAL
+		}
+		f.Symbols = append(f.Symbols, es)
+	}
+	ef, err := f.Open()
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	return ef
+}
+
+// sameSymbols compares the nm and native results, ignoring the section
+// index which nm doesn't report.
+func sameSymbols(t *testing.T, fromNM, native []Symbol) {
+	t.Helper()
+	byName := map[string][]Symbol{}
+	for _, s := range native {
+		s.Section = 0
+		byName[s.Name] = append(byName[s.Name], s)
+	}
+	for _, s := range fromNM {
+		found := false
+		for _, n := range byName[s.Name] {
+			if n == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expected %s, got %v", s, byName[s.Name])
+		}
+	}
+}
+
+func TestELFSymbolsParity(t *testing.T) {
+	fromNM, err := parseListSymbols(strings.NewReader(nmFixture))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	native, err := ELFSymbols(fixtureELF(t, fromNM))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(native) != len(fromNM) {
+		t.Fatalf("expected %d syms, got %d", len(fromNM), len(native))
+	}
+	if !sort.SliceIsSorted(native, func(i, j int) bool { return native[i].Name < native[j].Name }) {
+		t.Errorf("expected symbols sorted by name")
+	}
+	sameSymbols(t, fromNM, native)
+	for _, s := range native {
+		if s.Section == 0 {
+			t.Errorf("expected section index for %s", s)
+		}
+	}
+}
+
+func TestELFSymbolsMatchNM(t *testing.T) {
+	if !haveNM() {
+		t.Skip("nm not installed")
+	}
+	exe := elftest.GoBinary(t, helloProg)
+	out, err := exec.Command("nm", "-S", exe).Output()
+	if err != nil {
+		t.Skipf("running nm: %s", err)
+	}
+	fromNM, err := parseListSymbols(strings.NewReader(string(out)))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	f, err := elf.Open(exe)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	native, err := ELFSymbols(f)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(fromNM) == 0 {
+		t.Fatalf("expected symbols from nm")
+	}
+	sameSymbols(t, fromNM, native)
+}
+
+func TestELFSymbolKinds(t *testing.T) {
+	f := elftest.File{Type: elf.ET_REL}
+	text := f.AddSection(elftest.Section{Name: ".text", Type: elf.SHT_PROGBITS,
+		Flags: elf.SHF_ALLOC | elf.SHF_EXECINSTR, Data: make([]byte, 16)})
+	data := f.AddSection(elftest.Section{Name: ".data", Type: elf.SHT_PROGBITS,
+		Flags: elf.SHF_ALLOC | elf.SHF_WRITE, Data: make([]byte, 16)})
+	tbss := f.AddSection(elftest.Section{Name: ".tbss", Type: elf.SHT_NOBITS,
+		Flags: elf.SHF_ALLOC | elf.SHF_WRITE | elf.SHF_TLS, Size: 16})
+	f.Symbols = []elftest.Symbol{
+		{Name: "weakfn", Size: 4, Bind: elf.STB_WEAK, Type: elf.STT_FUNC, Section: text},
+		{Name: "ifunc", Value: 4, Size: 4, Bind: elf.STB_GLOBAL, Type: elf.STT_LOOS, Section: text},
+		{Name: "weakobj", Size: 8, Bind: elf.STB_WEAK, Type: elf.STT_OBJECT, Section: data},
+		{Name: "unique", Value: 8, Size: 8, Bind: elf.STB_LOOS, Type: elf.STT_OBJECT, Section: data},
+		{Name: "tlsvar", Size: 8, Bind: elf.STB_GLOBAL, Type: elf.STT_TLS, Section: tbss},
+		{Name: "abs", Value: 0x1234, Bind: elf.STB_GLOBAL, Type: elf.STT_NOTYPE, Section: elf.SHN_ABS},
+		{Name: "common", Value: 8, Size: 32, Bind: elf.STB_GLOBAL, Type: elf.STT_OBJECT, Section: elf.SHN_COMMON},
+		{Name: "undef", Bind: elf.STB_GLOBAL, Type: elf.STT_NOTYPE, Section: elf.SHN_UNDEF},
+		{Name: "weakundef", Bind: elf.STB_WEAK, Type: elf.STT_NOTYPE, Section: elf.SHN_UNDEF},
+		{Name: "weakundefobj", Bind: elf.STB_WEAK, Type: elf.STT_OBJECT, Section: elf.SHN_UNDEF},
+	}
+	ef, err := f.Open()
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	syms, err := ELFSymbols(ef)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	exp := map[string]struct {
+		typ  SymbolType
+		kind SymbolKind
+		bind Binding
+	}{
+		"weakfn":       {SymbolTypeWeak, KindText, BindingWeak},
+		"ifunc":        {SymbolTypeIndirectFunction, KindText, BindingGlobal},
+		"weakobj":      {SymbolTypeWeakObject, KindData, BindingWeak},
+		"unique":       {SymbolTypeUniqueGlobal, KindData, BindingUnique},
+		"tlsvar":       {SymbolTypeGlobalBSS, KindTLS, BindingGlobal},
+		"abs":          {SymbolTypeGlobalAbsolute, KindAbsolute, BindingGlobal},
+		"common":       {SymbolTypeGlobalCommon, KindCommon, BindingGlobal},
+		"undef":        {SymbolTypeUndefined, KindUndefined, BindingGlobal},
+		"weakundef":    {SymbolTypeWeakUndefined, KindUndefined, BindingWeak},
+		"weakundefobj": {SymbolTypeWeakObjectUndefined, KindUndefined, BindingWeak},
+	}
+	if len(syms) != len(exp) {
+		t.Fatalf("expected %d syms, got %d", len(exp), len(syms))
+	}
+	for _, s := range syms {
+		e := exp[s.Name]
+		if s.Type != e.typ || s.Kind != e.kind || s.Binding != e.bind {
+			t.Errorf("expected %s to be %s/%s/%s, got %s/%s/%s", s.Name,
+				e.typ, e.kind, e.bind, s.Type, s.Kind, s.Binding)
+		}
+	}
+}
+
+const libfooSrc = `
+int counter[4];
+int foo_v1(void) { return 1; }
+int foo_v2(void) { return 2; }
+__asm__(".symver foo_v1,foo@LIBFOO_1.0");
+__asm__(".symver foo_v2,foo@@LIBFOO_1.2");
+__attribute__((visibility("protected"))) int bar(void) { return 3; }
+__attribute__((visibility("hidden"))) int hid(void) { return 4; }
+__attribute__((weak)) int baz(void) { return 5; }
+`
+
+const libfooVersions = `
+LIBFOO_1.0 { global: counter; bar; baz; foo; hid; local: *; };
+LIBFOO_1.2 { global: foo; } LIBFOO_1.0;
+`
+
+func TestListExportsELF(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "libfoo.map")
+	if err := os.WriteFile(script, []byte(libfooVersions), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lib := elftest.CBinary(t, libfooSrc, "-shared", "-fPIC", "-Wl,--version-script="+script)
+	exps, err := ListExports(lib, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	exp := []Export{
+		{Name: "bar", Kind: "func", Version: "LIBFOO_1.0", Binding: BindingGlobal, Visibility: "protected"},
+		{Name: "baz", Kind: "func", Version: "LIBFOO_1.0", Binding: BindingWeak, Visibility: "default"},
+		{Name: "counter", Kind: "object", Version: "LIBFOO_1.0", Binding: BindingGlobal, Visibility: "default", Size: 16},
+		{Name: "foo", Kind: "func", Version: "LIBFOO_1.0", HiddenVersion: true, Binding: BindingGlobal, Visibility: "default"},
+		{Name: "foo", Kind: "func", Version: "LIBFOO_1.2", Binding: BindingGlobal, Visibility: "default"},
+	}
+	if len(exps) != len(exp) {
+		t.Fatalf("expected %d exports, got %v", len(exp), exps)
+	}
+	for i := range exps {
+		if exps[i] != exp[i] {
+			t.Errorf("expected %+v, got %+v", exp[i], exps[i])
+		}
+	}
+	if got := exps[3].String(); got != "foo@LIBFOO_1.0" {
+		t.Errorf("expected foo@LIBFOO_1.0, got %s", got)
+	}
+	if got := exps[4].String(); got != "foo@@LIBFOO_1.2" {
+		t.Errorf("expected foo@@LIBFOO_1.2, got %s", got)
+	}
+}
+
+func TestListImportsELF(t *testing.T) {
+	exe := elftest.CBinary(t, `#include <sys/random.h>
+int main(void) { char b[8]; return getrandom(b, sizeof(b), 0) < 0; }
+`)
+	imps, err := ListImports(exe, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	found := map[string]Import{}
+	for _, imp := range imps {
+		found[imp.Name] = imp
+	}
+	if _, ok := found[""]; !ok {
+		t.Errorf("expected a library entry in %v", imps)
+	}
+	if got := found["getrandom"]; got.Library != "libc.so.6" || got.Version != "GLIBC_2.25" {
+		t.Errorf("expected libc.so.6!getrandom@GLIBC_2.25, got %s", got)
+	}
+	for _, v := range MinimumVersions(imps) {
+		if v.Name == "GLIBC" && compareVersions(v.Version, "2.25") < 0 {
+			t.Errorf("expected GLIBC >= 2.25, got %s", v.Version)
+		}
+	}
+}
+
+func TestMinimumVersions(t *testing.T) {
+	imps := []Import{
+		{Library: "libc.so.6"},
+		{Library: "libc.so.6", Name: "memcpy", Version: "GLIBC_2.14"},
+		{Library: "libc.so.6", Name: "getrandom", Version: "GLIBC_2.25"},
+		{Library: "libc.so.6", Name: "puts", Version: "GLIBC_2.2.5"},
+		{Library: "libc.so.6", Name: "__libc_secret", Version: "GLIBC_PRIVATE"},
+		{Library: "libstdc++.so.6", Name: "_ZdlPv", Version: "GLIBCXX_3.4"},
+		{Library: "libstdc++.so.6", Name: "_ZSt9terminatev", Version: "GLIBCXX_3.4.21"},
+		{Library: "libstdc++.so.6", Name: "__cxa_begin_catch", Version: "CXXABI_1.3"},
+		{Name: "unversioned"},
+	}
+	exp := []Import{
+		{Library: "libstdc++.so.6", Name: "CXXABI", Version: "1.3"},
+		{Library: "libc.so.6", Name: "GLIBC", Version: "2.25"},
+		{Library: "libstdc++.so.6", Name: "GLIBCXX", Version: "3.4.21"},
+	}
+	got := MinimumVersions(imps)
+	if len(got) != len(exp) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+	for i := range got {
+		if got[i] != exp[i] {
+			t.Errorf("expected %+v, got %+v", exp[i], got[i])
+		}
+	}
+}
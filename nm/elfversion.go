@@ -0,0 +1,153 @@
+package nm
+
+import (
+	"debug/elf"
+	"fmt"
+)
+
+// elfVersion is the GNU symbol version of a dynamic symbol. Library is set
+// for versions required from another object.
+type elfVersion struct {
+	Name    string
+	Library string
+	// Hidden marks a non-default version of a defined symbol, which nm
+	// shows as name@version rather than name@@version
+	Hidden bool
+}
+
+const (
+	elfVersionHidden = 0x8000
+	elfVerFlagBase   = 0x1
+)
+
+// elfSymbolVersions reads the GNU versioning sections of f. The result is
+// indexed like the .gnu.version section, so entry 0 belongs to the null
+// symbol and entry i+1 to f.DynamicSymbols()[i]. It is nil if f doesn't use
+// symbol versioning.
+func elfSymbolVersions(f *elf.File) ([]elfVersion, error) {
+	versym := elfSectionByType(f, elf.SHT_GNU_VERSYM)
+	if versym == nil {
+		return nil, nil
+	}
+	byIndex := map[uint16]elfVersion{}
+	if s := elfSectionByType(f, elf.SHT_GNU_VERDEF); s != nil {
+		if err := elfVerdef(f, s, byIndex); err != nil {
+			return nil, err
+		}
+	}
+	if s := elfSectionByType(f, elf.SHT_GNU_VERNEED); s != nil {
+		if err := elfVerneed(f, s, byIndex); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := versym.Data()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]elfVersion, len(data)/2)
+	for i := range ret {
+		v := f.ByteOrder.Uint16(data[2*i:])
+		ver := byIndex[v&^elfVersionHidden]
+		ver.Hidden = v&elfVersionHidden != 0
+		ret[i] = ver
+	}
+	return ret, nil
+}
+
+func elfSectionByType(f *elf.File, typ elf.SectionType) *elf.Section {
+	for _, s := range f.Sections {
+		if s.Type == typ {
+			return s
+		}
+	}
+	return nil
+}
+
+// elfLinkedStrings returns the string table a versioning section refers to.
+func elfLinkedStrings(f *elf.File, s *elf.Section) ([]byte, error) {
+	if int(s.Link) >= len(f.Sections) {
+		return nil, fmt.Errorf("%s: bad string table index %d", s.Name, s.Link)
+	}
+	return f.Sections[s.Link].Data()
+}
+
+func elfString(strtab []byte, off uint32) string {
+	if int(off) >= len(strtab) {
+		return ""
+	}
+	end := int(off)
+	for end < len(strtab) && strtab[end] != 0 {
+		end++
+	}
+	return string(strtab[off:end])
+}
+
+// elfVerdef reads the versions defined by f. The base entry, which names
+// the object itself, is skipped.
+func elfVerdef(f *elf.File, s *elf.Section, byIndex map[uint16]elfVersion) error {
+	data, err := s.Data()
+	if err != nil {
+		return err
+	}
+	strtab, err := elfLinkedStrings(f, s)
+	if err != nil {
+		return err
+	}
+	bo := f.ByteOrder
+	for off := 0; ; {
+		// Elf_Verdef: version, flags, ndx, cnt uint16; hash, aux, next uint32
+		if off+20 > len(data) {
+			return fmt.Errorf("%s: truncated entry at %#x", s.Name, off)
+		}
+		flags := bo.Uint16(data[off+2:])
+		ndx := bo.Uint16(data[off+4:])
+		aux := off + int(bo.Uint32(data[off+12:]))
+		next := bo.Uint32(data[off+16:])
+		// the first Elf_Verdaux holds the version name
+		if flags&elfVerFlagBase == 0 && aux+8 <= len(data) {
+			byIndex[ndx] = elfVersion{Name: elfString(strtab, bo.Uint32(data[aux:]))}
+		}
+		if next == 0 {
+			return nil
+		}
+		off += int(next)
+	}
+}
+
+// elfVerneed reads the versions f requires from the libraries it links
+// against.
+func elfVerneed(f *elf.File, s *elf.Section, byIndex map[uint16]elfVersion) error {
+	data, err := s.Data()
+	if err != nil {
+		return err
+	}
+	strtab, err := elfLinkedStrings(f, s)
+	if err != nil {
+		return err
+	}
+	bo := f.ByteOrder
+	for off := 0; ; {
+		// Elf_Verneed: version, cnt uint16; file, aux, next uint32
+		if off+16 > len(data) {
+			return fmt.Errorf("%s: truncated entry at %#x", s.Name, off)
+		}
+		cnt := int(bo.Uint16(data[off+2:]))
+		lib := elfString(strtab, bo.Uint32(data[off+4:]))
+		aux := off + int(bo.Uint32(data[off+8:]))
+		for i := 0; i < cnt; i++ {
+			// Elf_Vernaux: hash uint32; flags, other uint16; name, next uint32
+			if aux+16 > len(data) {
+				return fmt.Errorf("%s: truncated entry at %#x", s.Name, aux)
+			}
+			ndx := bo.Uint16(data[aux+6:])
+			byIndex[ndx] = elfVersion{Name: elfString(strtab, bo.Uint32(data[aux+8:])), Library: lib}
+			aux += int(bo.Uint32(data[aux+12:]))
+		}
+		next := bo.Uint32(data[off+12:])
+		if next == 0 {
+			return nil
+		}
+		off += int(next)
+	}
+}
@@ -0,0 +1,190 @@
+package nm
+
+import (
+	"debug/dwarf"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/tzneal/bincmp/internal/objfile"
+)
+
+// Embed is a file or directory embedded in a Go binary by a //go:embed
+// directive on an embed.FS variable.
+type Embed struct {
+	// Var is the embed.FS variable, like "main.assets"
+	Var string
+	// Name is the path of the file in the file system, directories end in
+	// a slash
+	Name string
+	Size int64
+	// Hash is the hash of the contents of a file the embed package keeps
+	Hash string
+	// NameAddress and Address are the addresses of the name and contents,
+	// which the linker stores with the string literals
+	NameAddress, Address int64
+}
+
+func (e Embed) IsEmpty() bool {
+	return e.Var == ""
+}
+
+// IsDir reports whether e is a directory.
+func (e Embed) IsDir() bool {
+	return strings.HasSuffix(e.Name, "/")
+}
+
+// ListEmbeds returns the files and directories of the embed.FS variables of
+// a Go binary, sorted by variable and name. The variables are found by their
+// type in the DWARF info, or by the name the compiler gives their file
+// table, "<var>.files", in binaries linked without DWARF. Files embedded
+// into string or []byte variables can't be told apart from other
+// initialized variables and aren't listed.
+func ListEmbeds(filename, arch string) ([]Embed, error) {
+	f, err := objfile.Open(filename, arch)
+	if objfile.IsUnknownFormat(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if f.Archive != nil || f.Wasm != nil {
+		return nil, nil
+	}
+	img := newImage(f)
+
+	tables, err := dwarfEmbedTables(f, img)
+	if err != nil {
+		return nil, err
+	}
+	if tables == nil {
+		syms, err := symbolTable(f)
+		if err != nil {
+			return nil, err
+		}
+		tables = symtabEmbedTables(syms)
+	}
+
+	var ret []Embed
+	for name, addr := range tables {
+		ret = append(ret, embedFiles(img, name, addr)...)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Var != ret[j].Var {
+			return ret[i].Var < ret[j].Var
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret, nil
+}
+
+// embedHashSize is the size of the hash of an embedded file
+const embedHashSize = 16
+
+// embedFiles reads the file table of the embed.FS variable name. The table
+// starts with a slice header pointing right past it, followed by the name,
+// data and hash of each file, and is ignored if it doesn't:
+//
+//	type file struct {
+//		name string
+//		data string
+//		hash [16]byte
+//	}
+func embedFiles(img *image, name string, table int64) []Embed {
+	hdr := img.words(table, 3)
+	if hdr == nil || hdr[0] != table+3*int64(img.ptrSize) {
+		return nil
+	}
+	entSize := 4*int64(img.ptrSize) + embedHashSize
+	var ret []Embed
+	for i := int64(0); i < hdr[1]; i++ {
+		ent := hdr[0] + i*entSize
+		w := img.words(ent, 4)
+		if w == nil {
+			break
+		}
+		fileName := img.read(w[0], w[0]+w[1])
+		if fileName == nil {
+			break
+		}
+		e := Embed{Var: name, Name: string(fileName), Size: w[3], NameAddress: w[0], Address: w[2]}
+		if !e.IsDir() {
+			if hash := img.read(ent+4*int64(img.ptrSize), ent+entSize); hash != nil {
+				e.Hash = hex.EncodeToString(hash)
+			}
+		}
+		ret = append(ret, e)
+	}
+	return ret
+}
+
+// dwarfEmbedTables returns the addresses of the file tables of the package
+// level variables of type embed.FS by their names, or nil if f has no
+// DWARF info. The variables hold a pointer to their table.
+func dwarfEmbedTables(f *objfile.File, img *image) (map[string]int64, error) {
+	d := f.DWARF()
+	if d == nil {
+		return nil, nil
+	}
+
+	typeNames := map[dwarf.Offset]string{}
+	typeName := func(off dwarf.Offset) string {
+		if name, ok := typeNames[off]; ok {
+			return name
+		}
+		r := d.Reader()
+		r.Seek(off)
+		var name string
+		if e, err := r.Next(); err == nil && e != nil {
+			name, _ = e.Val(dwarf.AttrName).(string)
+		}
+		typeNames[off] = name
+		return name
+	}
+
+	ret := map[string]int64{}
+	r := d.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if e == nil {
+			break
+		}
+		if e.Tag != dwarf.TagVariable {
+			continue
+		}
+		typ, ok := e.Val(dwarf.AttrType).(dwarf.Offset)
+		if !ok || typeName(typ) != "embed.FS" {
+			continue
+		}
+		name, _ := e.Val(dwarf.AttrName).(string)
+		loc, _ := e.Val(dwarf.AttrLocation).([]byte)
+		// package level variables are located by a DW_OP_addr
+		if len(loc) != 1+img.ptrSize || loc[0] != dwOpAddr {
+			continue
+		}
+		if table := img.words(img.word(loc[1:]), 1); table != nil {
+			ret[name] = table[0]
+		}
+	}
+	return ret, nil
+}
+
+// dwOpAddr is the DWARF location operation pushing an address
+const dwOpAddr = 0x03
+
+// symtabEmbedTables returns the addresses of the symbols named like the
+// file tables of embed.FS variables, "<var>.files", by the name of the
+// variable.
+func symtabEmbedTables(syms []Symbol) map[string]int64 {
+	ret := map[string]int64{}
+	for _, s := range syms {
+		if strings.HasSuffix(s.Name, ".files") && s.Section != 0 {
+			ret[strings.TrimSuffix(s.Name, ".files")] = s.Value
+		}
+	}
+	return ret
+}
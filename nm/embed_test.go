@@ -0,0 +1,74 @@
+package nm
+
+import (
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+// embedProg embeds its own source and go.mod, the only files elftest
+// writes next to it.
+const embedProg = `package main
+
+import "embed"
+
+//go:embed main.go go.mod
+var files embed.FS
+
+func main() {
+	files.ReadFile("main.go")
+}
+`
+
+func TestListEmbeds(t *testing.T) {
+	tcs := []struct {
+		name string
+		env  map[string]string
+		args []string
+	}{
+		{name: "dwarf"},
+		{name: "symtab", args: []string{"-ldflags=-w"}},
+		{name: "pie", args: []string{"-buildmode=pie"}},
+		{name: "pe", env: map[string]string{"GOOS": "windows", "GOARCH": "amd64"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("CGO_ENABLED", "0")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			exe := elftest.GoBinary(t, embedProg, tc.args...)
+			embeds, err := ListEmbeds(exe, "")
+			if err != nil {
+				t.Fatalf("expected no error, got %s", err)
+			}
+			if len(embeds) != 2 {
+				t.Fatalf("expected 2 files, got %v", embeds)
+			}
+			// go build adds the go version to go.mod
+			if e := embeds[0]; e.Var != "main.files" || e.Name != "go.mod" || e.Size == 0 {
+				t.Errorf("expected go.mod, got %+v", e)
+			}
+			if e := embeds[1]; e.Var != "main.files" || e.Name != "main.go" || e.Size != int64(len(embedProg)) {
+				t.Errorf("expected main.go of size %d, got %+v", len(embedProg), e)
+			}
+			for _, e := range embeds {
+				if e.Hash == "" {
+					t.Errorf("expected a hash for %s", e.Name)
+				}
+			}
+			// the names and contents are stored with the string literals
+			strs, err := ListStrings(exe, "")
+			if err != nil {
+				t.Fatalf("expected no error, got %s", err)
+			}
+			byAddr := map[int64]string{}
+			for _, s := range strs {
+				byAddr[s.Address] = s.Value
+			}
+			if e := embeds[1]; byAddr[e.Address] != embedProg || byAddr[e.NameAddress] != "main.go" {
+				t.Errorf("expected the contents and name of main.go at %#x and %#x", e.Address, e.NameAddress)
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package nm
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tzneal/bincmp/internal/objfile"
+)
+
+// Import is something a binary needs to be provided at run time. Library
+// level entries, such as a DLL the binary links against, have no Name.
+type Import struct {
+	Library string
+	Name    string
+	// Version is the GNU symbol version an ELF import requires, e.g.
+	// "GLIBC_2.25"
+	Version string
+}
+
+func (i Import) IsEmpty() bool {
+	return len(i.Library) == 0 && len(i.Name) == 0
+}
+
+func (i Import) String() string {
+	if i.Name == "" {
+		return i.Library
+	}
+	return fmt.Sprintf("%s!%s", i.Library, i.Symbol())
+}
+
+// Symbol returns the name of the imported symbol, with its version appended
+// the way nm shows it.
+func (i Import) Symbol() string {
+	if i.Version == "" {
+		return i.Name
+	}
+	return i.Name + "@" + i.Version
+}
+
+// Export is something a binary provides to others at run time. Kind is
+// format specific, e.g. "func" or "memory" for WebAssembly, "func" or
+// "object" for ELF.
+type Export struct {
+	Name string
+	Kind string
+	// Version is the GNU symbol version of an ELF export, e.g. "LIBFOO_1.2",
+	// and HiddenVersion marks it as a non-default version
+	Version       string
+	HiddenVersion bool
+	Binding       Binding
+	// Visibility is the ELF symbol visibility, "default" or "protected"
+	Visibility string
+	// Size is only set for data objects, as the size of a function isn't
+	// part of the ABI
+	Size int64
+}
+
+func (e Export) IsEmpty() bool {
+	return len(e.Name) == 0
+}
+
+// String returns the name of the export, with its version appended the way
+// nm shows it.
+func (e Export) String() string {
+	switch {
+	case e.Version == "":
+		return e.Name
+	case e.HiddenVersion:
+		return e.Name + "@" + e.Version
+	default:
+		return e.Name + "@@" + e.Version
+	}
+}
+
+// ListImports lists the libraries and symbols imported by a binary. PE
+// import tables, WebAssembly imports and the undefined symbols of the ELF
+// dynamic symbol table are read; other formats report no imports.
+func ListImports(filename, arch string) ([]Import, error) {
+	f, err := objfile.Open(filename, arch)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ret []Import
+	switch {
+	case f.ELF != nil:
+		ret, err = elfImports(f.ELF)
+		if err != nil {
+			return nil, err
+		}
+	case f.PE != nil:
+		syms, err := f.PE.ImportedSymbols()
+		if err != nil {
+			return nil, err
+		}
+		libs := map[string]bool{}
+		for _, s := range syms {
+			// entries are in the form "symbol:library"
+			name, lib := s, ""
+			if i := strings.LastIndexByte(s, ':'); i >= 0 {
+				name, lib = s[:i], s[i+1:]
+			}
+			if !libs[lib] {
+				libs[lib] = true
+				ret = append(ret, Import{Library: lib})
+			}
+			ret = append(ret, Import{Library: lib, Name: name})
+		}
+	case f.Wasm != nil:
+		mods := map[string]bool{}
+		for _, imp := range f.Wasm.Imports {
+			if !mods[imp.Module] {
+				mods[imp.Module] = true
+				ret = append(ret, Import{Library: imp.Module})
+			}
+			ret = append(ret, Import{Library: imp.Module, Name: imp.Name})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Library != ret[j].Library {
+			return ret[i].Library < ret[j].Library
+		}
+		if ret[i].Name != ret[j].Name {
+			return ret[i].Name < ret[j].Name
+		}
+		return ret[i].Version < ret[j].Version
+	})
+	return dedupImports(ret), nil
+}
+
+// elfImports returns the libraries an ELF file links against and the
+// undefined symbols of its dynamic symbol table. Versioned symbols are
+// attributed to the library the version is required from; unversioned ones
+// have no library, as ELF doesn't say where they are expected to be found.
+func elfImports(f *elf.File) ([]Import, error) {
+	libs, err := f.ImportedLibraries()
+	if err != nil {
+		return nil, err
+	}
+	var ret []Import
+	for _, lib := range libs {
+		ret = append(ret, Import{Library: lib})
+	}
+
+	syms, err := f.DynamicSymbols()
+	if errors.Is(err, elf.ErrNoSymbols) {
+		return ret, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	versions, err := elfSymbolVersions(f)
+	if err != nil {
+		return nil, err
+	}
+	for i, s := range syms {
+		if s.Section != elf.SHN_UNDEF || s.Name == "" {
+			continue
+		}
+		imp := Import{Name: s.Name}
+		// versions are indexed from the null symbol DynamicSymbols omits
+		if i+1 < len(versions) {
+			imp.Library = versions[i+1].Library
+			imp.Version = versions[i+1].Name
+		}
+		ret = append(ret, imp)
+	}
+	return ret, nil
+}
+
+// MinimumVersions returns the newest version of each versioned interface,
+// such as GLIBC or GLIBCXX, the imports require: a binary importing
+// memcpy@GLIBC_2.14 and getrandom@GLIBC_2.25 needs at least GLIBC 2.25.
+// Versions without a numeric suffix, like GLIBC_PRIVATE, are ignored. The
+// result holds an Import named after each interface, e.g. {Library:
+// "libc.so.6", Name: "GLIBC", Version: "2.25"}, sorted by name.
+func MinimumVersions(imps []Import) []Import {
+	newest := map[string]Import{}
+	for _, imp := range imps {
+		name, ver := splitVersion(imp.Version)
+		if ver == "" {
+			continue
+		}
+		if cur, ok := newest[name]; !ok || compareVersions(ver, cur.Version) > 0 {
+			newest[name] = Import{Library: imp.Library, Name: name, Version: ver}
+		}
+	}
+	ret := make([]Import, 0, len(newest))
+	for _, imp := range newest {
+		ret = append(ret, imp)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// splitVersion splits "GLIBC_2.2.5" into "GLIBC" and "2.2.5". The version is
+// empty if v doesn't end in a dotted number.
+func splitVersion(v string) (string, string) {
+	i := strings.LastIndexByte(v, '_')
+	if i < 0 {
+		return v, ""
+	}
+	for _, part := range strings.Split(v[i+1:], ".") {
+		if _, err := strconv.Atoi(part); err != nil {
+			return v, ""
+		}
+	}
+	return v[:i], v[i+1:]
+}
+
+// compareVersions compares two dotted version numbers.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// dedupImports removes repeated entries from a sorted list.
+func dedupImports(imps []Import) []Import {
+	ret := imps[:0]
+	for _, imp := range imps {
+		if len(ret) > 0 && imp == ret[len(ret)-1] {
+			continue
+		}
+		ret = append(ret, imp)
+	}
+	return ret
+}
+
+var errNoExports = errors.New("reading exports is only supported for ELF and WebAssembly files")
+
+// ListExports lists the entities a WebAssembly module exports, or the
+// symbols an ELF shared object or executable exports through its dynamic
+// symbol table. Other formats return an error, so that callers checking for
+// removed exports don't pass for lack of any.
+func ListExports(filename, arch string) ([]Export, error) {
+	f, err := objfile.Open(filename, arch)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ret []Export
+	switch {
+	case f.ELF != nil:
+		ret, err = elfExports(f.ELF)
+		if err != nil {
+			return nil, err
+		}
+	case f.Wasm != nil:
+		for _, e := range f.Wasm.Exports {
+			ret = append(ret, Export{Name: e.Name, Kind: e.Kind.String()})
+		}
+	default:
+		return nil, fmt.Errorf("%s: %w", filename, errNoExports)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Name != ret[j].Name {
+			return ret[i].Name < ret[j].Name
+		}
+		return ret[i].Version < ret[j].Version
+	})
+	return ret, nil
+}
+
+// elfExports returns the defined global and weak symbols of the dynamic
+// symbol table that other objects can bind to.
+func elfExports(f *elf.File) ([]Export, error) {
+	syms, err := f.DynamicSymbols()
+	if errors.Is(err, elf.ErrNoSymbols) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	versions, err := elfSymbolVersions(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []Export
+	for i, s := range syms {
+		bind := elfBinding(s)
+		vis := elf.ST_VISIBILITY(s.Other)
+		if s.Section == elf.SHN_UNDEF || bind == BindingLocal ||
+			(vis != elf.STV_DEFAULT && vis != elf.STV_PROTECTED) {
+			continue
+		}
+		e := Export{
+			Name:       s.Name,
+			Kind:       elfExportKind(s),
+			Binding:    bind,
+			Visibility: elfVisibility(vis),
+		}
+		switch elf.ST_TYPE(s.Info) {
+		case elf.STT_OBJECT, elf.STT_TLS, elf.STT_COMMON:
+			e.Size = int64(s.Size)
+		}
+		// versions are indexed from the null symbol DynamicSymbols omits
+		if i+1 < len(versions) {
+			e.Version = versions[i+1].Name
+			e.HiddenVersion = versions[i+1].Hidden
+		}
+		// each version defined by the object also has an absolute symbol
+		// named after it
+		if s.Section == elf.SHN_ABS && s.Name == e.Version {
+			continue
+		}
+		ret = append(ret, e)
+	}
+	return ret, nil
+}
+
+func elfExportKind(s elf.Symbol) string {
+	switch elf.ST_TYPE(s.Info) {
+	case elf.STT_FUNC:
+		return "func"
+	case elf.STT_OBJECT:
+		return "object"
+	case elf.STT_TLS:
+		return "tls"
+	case elf.STT_COMMON:
+		return "common"
+	case elf.STT_LOOS:
+		// STT_GNU_IFUNC
+		return "ifunc"
+	}
+	return "notype"
+}
+
+func elfVisibility(v elf.SymVis) string {
+	switch v {
+	case elf.STV_DEFAULT:
+		return "default"
+	case elf.STV_PROTECTED:
+		return "protected"
+	case elf.STV_HIDDEN:
+		return "hidden"
+	}
+	return "internal"
+}
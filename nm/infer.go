@@ -0,0 +1,34 @@
+package nm
+
+import "sort"
+
+// inferSizes sets the size of every symbol defined in a section to the
+// distance to the next symbol in the same section, or to the end of the
+// section for the last one. This is how sizes are recovered for formats
+// whose symbol tables don't record them. ends maps section indexes to the
+// address just past the section.
+func inferSizes(syms []Symbol, ends map[int]int64) {
+	addrs := map[int][]int64{}
+	for _, s := range syms {
+		if s.Section != 0 {
+			addrs[s.Section] = append(addrs[s.Section], s.Value)
+		}
+	}
+	for _, a := range addrs {
+		sort.Slice(a, func(i, j int) bool { return a[i] < a[j] })
+	}
+	for i, s := range syms {
+		if s.Section == 0 {
+			continue
+		}
+		a := addrs[s.Section]
+		next := sort.Search(len(a), func(x int) bool { return a[x] > s.Value })
+		end := ends[s.Section]
+		if next < len(a) {
+			end = a[next]
+		}
+		if end > s.Value {
+			syms[i].Size = end - s.Value
+		}
+	}
+}
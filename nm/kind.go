@@ -0,0 +1,90 @@
+package nm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SymbolKind is what a symbol is, independent of its binding.
+type SymbolKind byte
+
+const (
+	KindUnknown SymbolKind = iota
+	KindText
+	KindData
+	KindReadOnlyData
+	KindBSS
+	// KindTLS is thread-local storage, initialized or not
+	KindTLS
+	KindUndefined
+	KindAbsolute
+	KindCommon
+	KindDebug
+)
+
+var kindNames = []string{"unknown", "text", "data", "rodata", "bss", "tls",
+	"undefined", "absolute", "common", "debug"}
+
+func (k SymbolKind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("SymbolKind(%d)", k)
+}
+
+// ParseKind returns the kind named s, as printed by SymbolKind.String.
+func ParseKind(s string) (SymbolKind, error) {
+	for i, n := range kindNames {
+		if strings.EqualFold(n, s) {
+			return SymbolKind(i), nil
+		}
+	}
+	return KindUnknown, fmt.Errorf("unknown symbol kind %q, expected one of %s",
+		s, strings.Join(kindNames, ", "))
+}
+
+// symbolType returns the nm type matching a kind and binding, for the
+// formats that don't mark weak objects or indirect functions.
+func symbolType(kind SymbolKind, bind Binding) SymbolType {
+	global := bind != BindingLocal
+	switch {
+	case bind == BindingUnique:
+		return SymbolTypeUniqueGlobal
+	case kind == KindUndefined && bind == BindingWeak:
+		return SymbolTypeWeakUndefined
+	case kind == KindUndefined:
+		return SymbolTypeUndefined
+	case bind == BindingWeak && kind == KindText:
+		return SymbolTypeWeak
+	case bind == BindingWeak:
+		return SymbolTypeWeakObject
+	}
+	switch kind {
+	case KindText:
+		return pick(global, SymbolTypeText, SymbolTypeGlobalText)
+	case KindData:
+		return pick(global, SymbolTypeData, SymbolTypeGlobalData)
+	case KindReadOnlyData:
+		return pick(global, SymbolTypeReadOnlyData, SymbolTypeGlobalReadOnlyData)
+	case KindBSS:
+		return pick(global, SymbolTypeBSS, SymbolTypeGlobalBSS)
+	case KindTLS:
+		// nm reports thread-local symbols as data or bss depending on their
+		// section, callers that know the section handle bss themselves
+		return pick(global, SymbolTypeData, SymbolTypeGlobalData)
+	case KindAbsolute:
+		return pick(global, SymbolTypeAbsolute, SymbolTypeGlobalAbsolute)
+	case KindCommon:
+		return pick(global, SymbolTypeCommon, SymbolTypeGlobalCommon)
+	case KindDebug:
+		return SymbolTypeDebug
+	}
+	return SymbolTypeUnknown
+}
+
+func pick(global bool, local, glob SymbolType) SymbolType {
+	if global {
+		return glob
+	}
+	return local
+}
@@ -0,0 +1,125 @@
+package nm
+
+import (
+	"debug/macho"
+	"sort"
+)
+
+// Mach-O nlist type bits
+const (
+	machoStab     = 0xe0
+	machoTypeMask = 0x0e
+	machoExt      = 0x01
+	machoUndef    = 0x00
+	machoAbs      = 0x02
+	machoSect     = 0x0e
+	machoWeakRef  = 0x40
+	machoWeakDef  = 0x80
+)
+
+// Mach-O section types and attributes
+const (
+	machoSectionType      = 0xff
+	machoZerofill         = 0x01
+	machoGBZerofill       = 0x0c
+	machoTLVRegular       = 0x11
+	machoTLVZerofill      = 0x12
+	machoTLVVariables     = 0x13
+	machoPureInstructions = 0x80000000
+	machoSomeInstructions = 0x00000400
+	machoTextSegment      = "__TEXT"
+	machoDataConstSegment = "__DATA_CONST"
+)
+
+// MachOSymbols reads the symbol table of a Mach-O file. Mach-O doesn't
+// record symbol sizes, so the size of each symbol defined in a section is
+// the distance to the next symbol in the same section, or to the end of the
+// section for the last one. Debugging (stab) entries are skipped.
+func MachOSymbols(f *macho.File) ([]Symbol, error) {
+	if f.Symtab == nil {
+		return []Symbol{}, nil
+	}
+
+	ret := make([]Symbol, 0, len(f.Symtab.Syms))
+	for _, s := range f.Symtab.Syms {
+		if s.Type&machoStab != 0 {
+			continue
+		}
+		bind := machoBinding(s)
+		sym := Symbol{
+			Name:    s.Name,
+			Kind:    machoUndefinedKind(s),
+			Value:   int64(s.Value),
+			Binding: bind,
+		}
+		if s.Type&machoTypeMask == machoSect && s.Sect > 0 && int(s.Sect) <= len(f.Sections) {
+			sym.Section = int(s.Sect)
+			sym.Kind = machoKind(f.Sections[s.Sect-1])
+		}
+		sym.Type = symbolType(sym.Kind, bind)
+		if sym.Kind == KindTLS && machoIsZerofill(f.Sections[s.Sect-1]) {
+			sym.Type = pick(bind != BindingLocal, SymbolTypeBSS, SymbolTypeGlobalBSS)
+		}
+		ret = append(ret, sym)
+	}
+
+	ends := make(map[int]int64, len(f.Sections))
+	for i, sect := range f.Sections {
+		ends[i+1] = int64(sect.Addr + sect.Size)
+	}
+	inferSizes(ret, ends)
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret, nil
+}
+
+// machoBinding maps the external bit and weak flags to a Binding.
+func machoBinding(s macho.Symbol) Binding {
+	switch {
+	case s.Type&machoExt == 0:
+		return BindingLocal
+	case s.Desc&(machoWeakDef|machoWeakRef) != 0:
+		return BindingWeak
+	default:
+		return BindingGlobal
+	}
+}
+
+// machoUndefinedKind classifies the symbols that aren't defined in a
+// section. Undefined external symbols with a value are common symbols of
+// that size.
+func machoUndefinedKind(s macho.Symbol) SymbolKind {
+	switch s.Type & machoTypeMask {
+	case machoUndef:
+		if s.Value != 0 {
+			return KindCommon
+		}
+		return KindUndefined
+	case machoAbs:
+		return KindAbsolute
+	}
+	return KindUnknown
+}
+
+// machoKind classifies a symbol by the section it is defined in.
+func machoKind(sect *macho.Section) SymbolKind {
+	switch typ := sect.Flags & machoSectionType; {
+	case sect.Flags&(machoPureInstructions|machoSomeInstructions) != 0:
+		return KindText
+	case typ == machoTLVRegular || typ == machoTLVZerofill || typ == machoTLVVariables:
+		return KindTLS
+	case machoIsZerofill(sect):
+		return KindBSS
+	case sect.Seg == machoTextSegment || sect.Seg == machoDataConstSegment:
+		return KindReadOnlyData
+	default:
+		return KindData
+	}
+}
+
+func machoIsZerofill(sect *macho.Section) bool {
+	typ := sect.Flags & machoSectionType
+	return typ == machoZerofill || typ == machoGBZerofill || typ == machoTLVZerofill
+}
@@ -0,0 +1,71 @@
+package nm
+
+import (
+	"debug/macho"
+	"errors"
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+func TestMachOSymbols(t *testing.T) {
+	t.Setenv("GOOS", "darwin")
+	t.Setenv("GOARCH", "arm64")
+	t.Setenv("CGO_ENABLED", "0")
+	f, err := macho.Open(elftest.GoBinary(t, helloProg))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	defer f.Close()
+	syms, err := MachOSymbols(f)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	var mainSym Symbol
+	bySect := map[int][]Symbol{}
+	for _, s := range syms {
+		if s.Name == "main.main" {
+			mainSym = s
+		}
+		if s.Section != 0 {
+			bySect[s.Section] = append(bySect[s.Section], s)
+		}
+	}
+	if mainSym.Type != SymbolTypeText && mainSym.Type != SymbolTypeGlobalText {
+		t.Errorf("expected main.main in text, got %s", mainSym)
+	}
+	if mainSym.Size == 0 {
+		t.Errorf("expected a size for main.main")
+	}
+
+	// inferred sizes can't overlap or extend past the end of the section
+	for idx, ss := range bySect {
+		sect := f.Sections[idx-1]
+		var total int64
+		seen := map[int64]bool{}
+		for _, s := range ss {
+			if s.Value+s.Size > int64(sect.Addr+sect.Size) {
+				t.Errorf("%s extends past the end of %s", s, sect.Name)
+			}
+			if !seen[s.Value] {
+				total += s.Size
+				seen[s.Value] = true
+			}
+		}
+		if total > int64(sect.Size) {
+			t.Errorf("expected at most %d bytes in %s, got %d", sect.Size, sect.Name, total)
+		}
+	}
+}
+
+func TestListExportsMachO(t *testing.T) {
+	t.Setenv("GOOS", "darwin")
+	t.Setenv("GOARCH", "arm64")
+	t.Setenv("CGO_ENABLED", "0")
+	// reading no exports would let -abi pass whatever was removed
+	_, err := ListExports(elftest.GoBinary(t, helloProg), "")
+	if !errors.Is(err, errNoExports) {
+		t.Errorf("expected an unsupported format error, got %v", err)
+	}
+}
@@ -8,9 +8,15 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"unicode"
+
+	"github.com/tzneal/bincmp/internal/objfile"
 )
 
-// SymbolType is the type of symbol parsed from the nm output
+// SymbolType is the type of symbol parsed from the nm output. It mirrors
+// the nm type character; the Kind and Binding of a symbol carry the same
+// information in separate fields.
+//
 //go:generate stringer -type=SymbolType
 type SymbolType byte
 
@@ -24,13 +30,54 @@ const (
 	SymbolTypeGlobalText
 	SymbolTypeReadOnlyData
 	SymbolTypeGlobalReadOnlyData
+	SymbolTypeUndefined
+	SymbolTypeWeak
+	SymbolTypeWeakUndefined
+	SymbolTypeWeakObject
+	SymbolTypeWeakObjectUndefined
+	SymbolTypeAbsolute
+	SymbolTypeGlobalAbsolute
+	SymbolTypeCommon
+	SymbolTypeGlobalCommon
+	SymbolTypeUniqueGlobal
+	SymbolTypeIndirectFunction
+	SymbolTypeDebug
+)
+
+// Binding is the linkage visibility of a symbol
+//
+//go:generate stringer -type=Binding
+type Binding byte
+
+const (
+	BindingUnknown Binding = iota
+	BindingLocal
+	BindingGlobal
+	BindingWeak
+	// BindingUnique is a GNU extension, a global symbol the dynamic linker
+	// makes sure is unique across the process
+	BindingUnique
 )
 
 type Symbol struct {
 	Name  string
 	Type  SymbolType
+	Kind  SymbolKind
 	Size  int64
 	Value int64
+	// Section is the index of the section the symbol is defined in, as
+	// stored in the object file. It is zero for undefined symbols and when
+	// the symbol was read from nm output.
+	Section int
+	Binding Binding
+	// Member is the archive member defining the symbol, for symbols read
+	// from static archives.
+	Member string
+	// Demangled is the demangled name of C++ and Rust symbols, set by
+	// Demangle.
+	Demangled string
+	// Hash identifies the contents of the symbol, set by HashContents.
+	Hash string
 }
 
 func (s Symbol) IsEmpty() bool {
@@ -41,7 +88,75 @@ func (s Symbol) String() string {
 	return fmt.Sprintf("<%s %s %d>", s.Name, s.Type, s.Size)
 }
 
+// ListSymbols reads the symbols of an ELF, Mach-O, PE or WebAssembly
+// binary, or of the object files in a static archive. Files in other
+// formats are handed to nm if it is installed.
 func ListSymbols(filename string) ([]Symbol, error) {
+	return ListSymbolsArch(filename, "")
+}
+
+// ListSymbolsArch is like ListSymbols, but reads the slice for arch out of
+// Mach-O universal binaries.
+func ListSymbolsArch(filename, arch string) ([]Symbol, error) {
+	f, err := objfile.Open(filename, arch)
+	if err != nil {
+		if objfile.IsUnknownFormat(err) && haveNM() {
+			return ListSymbolsNM(filename)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	if f.Archive != nil {
+		return archiveSymbols(filename, f, arch)
+	}
+	return fileSymbols(f)
+}
+
+// fileSymbols reads the symbol table of f. If it has no defined symbols, as
+// in binaries linked with -ldflags=-s, Go binaries fall back to their
+// pclntab. Stripped Mach-O binaries keep their undefined dyld imports, which
+// are kept alongside.
+func fileSymbols(f *objfile.File) ([]Symbol, error) {
+	syms, err := symbolTable(f)
+	if err != nil || f.Wasm != nil {
+		return syms, err
+	}
+	for _, s := range syms {
+		if s.Section != 0 {
+			return syms, nil
+		}
+	}
+	gosyms, err := goSymbols(f)
+	if objfile.IsNoPcln(err) {
+		return syms, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(gosyms, syms...), nil
+}
+
+func symbolTable(f *objfile.File) ([]Symbol, error) {
+	switch {
+	case f.ELF != nil:
+		return ELFSymbols(f.ELF)
+	case f.MachO != nil:
+		return MachOSymbols(f.MachO)
+	case f.PE != nil:
+		return PESymbols(f.PE)
+	case f.Wasm != nil:
+		return WasmSymbols(f.Wasm), nil
+	}
+	return nil, fmt.Errorf("unsupported object file")
+}
+
+func haveNM() bool {
+	_, err := exec.LookPath("nm")
+	return err == nil
+}
+
+// ListSymbolsNM lists symbols by parsing the output of "nm -S".
+func ListSymbolsNM(filename string) ([]Symbol, error) {
 	args := []string{"-S", filename}
 	cmd := exec.Command("nm", args...)
 	p, err := cmd.StdoutPipe()
@@ -58,11 +173,25 @@ func ListSymbols(filename string) ([]Symbol, error) {
 func parseListSymbols(r io.Reader) ([]Symbol, error) {
 	scanner := bufio.NewScanner(r)
 	ret := []Symbol{}
+	member := ""
 	for scanner.Scan() {
+		// archive members are introduced by a "member.o:" line
+		if text := scanner.Text(); strings.HasSuffix(text, ":") && !strings.ContainsAny(text, " \t") {
+			member = strings.TrimSuffix(text, ":")
+			continue
+		}
 		line := strings.Fields(scanner.Text())
 		// format is "address size type name" with
 		// - type being 1 character
 		// - symbol possibly having spaces
+		// - address and size missing for undefined symbols, and size
+		//   missing for symbols that don't have one
+		switch {
+		case len(line) >= 2 && len(line[0]) == 1:
+			line = append([]string{"0", "0"}, line...)
+		case len(line) >= 3 && len(line[1]) == 1:
+			line = append([]string{line[0], "0"}, line[1:]...)
+		}
 		if len(line) < 4 || len(line[2]) != 1 {
 			continue
 		}
@@ -70,6 +199,7 @@ func parseListSymbols(r io.Reader) ([]Symbol, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error parsing symbol: %s", err)
 		}
+		sym.Member = member
 		ret = append(ret, sym)
 	}
 	return ret, nil
@@ -90,29 +220,69 @@ func parseSymbol(line []string) (Symbol, error) {
 		return Symbol{}, errors.New(fmt.Sprintf("couldn't parse size %s", line[1]))
 	}
 	name := strings.Join(line[3:], " ")
-	return Symbol{name, decodeType(line[2]), size, value}, nil
+	return Symbol{
+		Name:    name,
+		Type:    decodeType(line[2]),
+		Kind:    decodeKind(line[2]),
+		Size:    size,
+		Value:   value,
+		Binding: decodeBinding(line[2]),
+	}, nil
+}
+
+// nmTypes describes the nm type characters
+var nmTypes = map[string]struct {
+	typ  SymbolType
+	kind SymbolKind
+	bind Binding
+}{
+	"b": {SymbolTypeBSS, KindBSS, BindingLocal},
+	"B": {SymbolTypeGlobalBSS, KindBSS, BindingGlobal},
+	"s": {SymbolTypeBSS, KindBSS, BindingLocal},
+	"S": {SymbolTypeGlobalBSS, KindBSS, BindingGlobal},
+	"d": {SymbolTypeData, KindData, BindingLocal},
+	"D": {SymbolTypeGlobalData, KindData, BindingGlobal},
+	"g": {SymbolTypeData, KindData, BindingLocal},
+	"G": {SymbolTypeGlobalData, KindData, BindingGlobal},
+	"t": {SymbolTypeText, KindText, BindingLocal},
+	"T": {SymbolTypeGlobalText, KindText, BindingGlobal},
+	"r": {SymbolTypeReadOnlyData, KindReadOnlyData, BindingLocal},
+	"R": {SymbolTypeGlobalReadOnlyData, KindReadOnlyData, BindingGlobal},
+	"U": {SymbolTypeUndefined, KindUndefined, BindingGlobal},
+	// nm doesn't say where weak symbols live; W is usually a function and
+	// V is an object
+	"W": {SymbolTypeWeak, KindText, BindingWeak},
+	"w": {SymbolTypeWeakUndefined, KindUndefined, BindingWeak},
+	"V": {SymbolTypeWeakObject, KindData, BindingWeak},
+	"v": {SymbolTypeWeakObjectUndefined, KindUndefined, BindingWeak},
+	"a": {SymbolTypeAbsolute, KindAbsolute, BindingLocal},
+	"A": {SymbolTypeGlobalAbsolute, KindAbsolute, BindingGlobal},
+	"c": {SymbolTypeCommon, KindCommon, BindingLocal},
+	"C": {SymbolTypeGlobalCommon, KindCommon, BindingGlobal},
+	"u": {SymbolTypeUniqueGlobal, KindData, BindingUnique},
+	"i": {SymbolTypeIndirectFunction, KindText, BindingGlobal},
+	"n": {SymbolTypeDebug, KindDebug, BindingLocal},
+	"N": {SymbolTypeDebug, KindDebug, BindingLocal},
 }
 
 // decodeType maps section type characters to a more readable section name.
 func decodeType(t string) SymbolType {
-	switch t {
-	case "b":
-		return SymbolTypeBSS
-	case "B":
-		return SymbolTypeGlobalBSS
-	case "d":
-		return SymbolTypeData
-	case "D":
-		return SymbolTypeGlobalData
-	case "t":
-		return SymbolTypeText
-	case "T":
-		return SymbolTypeGlobalText
-	case "r":
-		return SymbolTypeReadOnlyData
-	case "R":
-		return SymbolTypeGlobalReadOnlyData
-	default:
-		return SymbolTypeUnknown
+	return nmTypes[t].typ
+}
+
+// decodeKind maps the nm type character to the kind of the symbol.
+func decodeKind(t string) SymbolKind {
+	return nmTypes[t].kind
+}
+
+// decodeBinding derives the symbol binding from the nm type character, which
+// is lower case for local symbols.
+func decodeBinding(t string) Binding {
+	if b, ok := nmTypes[t]; ok {
+		return b.bind
+	}
+	if unicode.IsUpper(rune(t[0])) {
+		return BindingGlobal
 	}
+	return BindingLocal
 }
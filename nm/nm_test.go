@@ -5,8 +5,7 @@ import (
 	"testing"
 )
 
-func TestListSymbols(t *testing.T) {
-	inp := `0000000000a95240 0000000000000008 B encoding/xml.HTMLEntity
+const nmFixture = `0000000000a95240 0000000000000008 B encoding/xml.HTMLEntity
 0000000000a877d8 0000000000000008 D encoding/xml.second
 0000000000a95258 0000000000000008 B encoding/xml.tinfoMap
 00000000008d2f18 0000000000000008 r $f64.0010000000000000
@@ -14,16 +13,18 @@ func TestListSymbols(t *testing.T) {
 0000000000456730 0000000000000009 T runtime.prefetchnta
 0000000000456700 0000000000000009 T runtime.prefetcht0
 `
+
+func TestListSymbols(t *testing.T) {
 	exp := []Symbol{
-		{"encoding/xml.HTMLEntity", SymbolTypeGlobalBSS, 8, 0xa95240},
-		{"encoding/xml.second", SymbolTypeGlobalData, 8, 0xa877d8},
-		{"encoding/xml.tinfoMap", SymbolTypeGlobalBSS, 8, 0xa95258},
-		{"$f64.0010000000000000", SymbolTypeReadOnlyData, 8, 0x8d2f18},
-		{"$f64.3cb0000000000000", SymbolTypeReadOnlyData, 8, 0x8d2f20},
-		{"runtime.prefetchnta", SymbolTypeGlobalText, 9, 0x456730},
-		{"runtime.prefetcht0", SymbolTypeGlobalText, 9, 0x456700}}
+		{Name: "encoding/xml.HTMLEntity", Type: SymbolTypeGlobalBSS, Kind: KindBSS, Size: 8, Value: 0xa95240, Binding: BindingGlobal},
+		{Name: "encoding/xml.second", Type: SymbolTypeGlobalData, Kind: KindData, Size: 8, Value: 0xa877d8, Binding: BindingGlobal},
+		{Name: "encoding/xml.tinfoMap", Type: SymbolTypeGlobalBSS, Kind: KindBSS, Size: 8, Value: 0xa95258, Binding: BindingGlobal},
+		{Name: "$f64.0010000000000000", Type: SymbolTypeReadOnlyData, Kind: KindReadOnlyData, Size: 8, Value: 0x8d2f18, Binding: BindingLocal},
+		{Name: "$f64.3cb0000000000000", Type: SymbolTypeReadOnlyData, Kind: KindReadOnlyData, Size: 8, Value: 0x8d2f20, Binding: BindingLocal},
+		{Name: "runtime.prefetchnta", Type: SymbolTypeGlobalText, Kind: KindText, Size: 9, Value: 0x456730, Binding: BindingGlobal},
+		{Name: "runtime.prefetcht0", Type: SymbolTypeGlobalText, Kind: KindText, Size: 9, Value: 0x456700, Binding: BindingGlobal}}
 
-	syms, err := parseListSymbols(strings.NewReader(inp))
+	syms, err := parseListSymbols(strings.NewReader(nmFixture))
 	if err != nil {
 		t.Fatalf("expected no error, got %s", err)
 	}
@@ -36,3 +37,75 @@ func TestListSymbols(t *testing.T) {
 		}
 	}
 }
+
+func TestListSymbolsMembers(t *testing.T) {
+	inp := `
+go.o:
+0000000000008ba0 0000000000000008 r $f64.3eb0000000000000
+0000000000000000 0000000000000009 T Foo
+
+000000.o:
+0000000000000000 0000000000000047 T Foo
+`
+	syms, err := parseListSymbols(strings.NewReader(inp))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	exp := []string{"go.o", "go.o", "000000.o"}
+	if len(syms) != len(exp) {
+		t.Fatalf("expected %d syms, got %d", len(exp), len(syms))
+	}
+	for i := range syms {
+		if syms[i].Member != exp[i] {
+			t.Errorf("expected %s in member %s, got %s", syms[i], exp[i], syms[i].Member)
+		}
+	}
+}
+
+func TestListSymbolsKinds(t *testing.T) {
+	inp := `                 U puts@GLIBC_2.2.5
+                 w __gmon_start__
+0000000000004010 0000000000000004 V weakobj
+0000000000001139 000000000000000b W weakfn
+0000000000000000 0000000000000008 b tlsvar
+0000000000000020 0000000000000020 C common
+0000000000001234 A abs
+0000000000004018 0000000000000008 u unique
+0000000000001150 0000000000000010 i ifunc
+`
+	exp := []Symbol{
+		{Name: "puts@GLIBC_2.2.5", Type: SymbolTypeUndefined, Kind: KindUndefined, Binding: BindingGlobal},
+		{Name: "__gmon_start__", Type: SymbolTypeWeakUndefined, Kind: KindUndefined, Binding: BindingWeak},
+		{Name: "weakobj", Type: SymbolTypeWeakObject, Kind: KindData, Size: 4, Value: 0x4010, Binding: BindingWeak},
+		{Name: "weakfn", Type: SymbolTypeWeak, Kind: KindText, Size: 0xb, Value: 0x1139, Binding: BindingWeak},
+		{Name: "tlsvar", Type: SymbolTypeBSS, Kind: KindBSS, Size: 8, Binding: BindingLocal},
+		{Name: "common", Type: SymbolTypeGlobalCommon, Kind: KindCommon, Size: 0x20, Value: 0x20, Binding: BindingGlobal},
+		{Name: "abs", Type: SymbolTypeGlobalAbsolute, Kind: KindAbsolute, Value: 0x1234, Binding: BindingGlobal},
+		{Name: "unique", Type: SymbolTypeUniqueGlobal, Kind: KindData, Size: 8, Value: 0x4018, Binding: BindingUnique},
+		{Name: "ifunc", Type: SymbolTypeIndirectFunction, Kind: KindText, Size: 0x10, Value: 0x1150, Binding: BindingGlobal},
+	}
+	syms, err := parseListSymbols(strings.NewReader(inp))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(syms) != len(exp) {
+		t.Fatalf("expected %d syms, got %d", len(exp), len(syms))
+	}
+	for i := range syms {
+		if syms[i] != exp[i] {
+			t.Errorf("expected %+v, got %+v", exp[i], syms[i])
+		}
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	for k := KindUnknown; k <= KindDebug; k++ {
+		got, err := ParseKind(k.String())
+		if err != nil || got != k {
+			t.Errorf("expected %s, got %s (%v)", k, got, err)
+		}
+	}
+	if _, err := ParseKind("bogus"); err == nil {
+		t.Errorf("expected error for unknown kind")
+	}
+}
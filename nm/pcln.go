@@ -0,0 +1,83 @@
+package nm
+
+import (
+	"debug/gosym"
+	"sort"
+
+	"github.com/tzneal/bincmp/internal/objfile"
+)
+
+// ListGoSymbols lists the functions of a Go binary as recorded in its
+// pclntab, which is kept even when the symbol table is stripped with
+// -ldflags=-s. A function's size runs up to the start of the next one, so
+// unlike the symbol table it includes alignment padding. ABI wrappers share
+// the name of the function they wrap in the pclntab, rather than having a
+// ".abi0" suffix, so the sizes of functions sharing a name are summed.
+func ListGoSymbols(filename, arch string) ([]Symbol, error) {
+	f, err := objfile.Open(filename, arch)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return goSymbols(f)
+}
+
+func goSymbols(f *objfile.File) ([]Symbol, error) {
+	textStart, pclntab, err := f.Pcln()
+	if err != nil {
+		return nil, err
+	}
+	tab, err := gosym.NewTable(nil, gosym.NewLineTable(pclntab, textStart))
+	if err != nil {
+		return nil, err
+	}
+
+	textIdx := textSection(f)
+	ret := make([]Symbol, 0, len(tab.Funcs))
+	seen := make(map[string]int, len(tab.Funcs))
+	for _, fn := range tab.Funcs {
+		if i, ok := seen[fn.Name]; ok {
+			ret[i].Size += int64(fn.End - fn.Entry)
+			continue
+		}
+		seen[fn.Name] = len(ret)
+		ret = append(ret, Symbol{
+			Name:    fn.Name,
+			Type:    SymbolTypeGlobalText,
+			Kind:    KindText,
+			Size:    int64(fn.End - fn.Entry),
+			Value:   int64(fn.Entry),
+			Section: textIdx,
+			Binding: BindingGlobal,
+		})
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret, nil
+}
+
+// textSection returns the index of the section holding Go code.
+func textSection(f *objfile.File) int {
+	switch {
+	case f.ELF != nil:
+		for i, s := range f.ELF.Sections {
+			if s.Name == ".text" {
+				return i
+			}
+		}
+	case f.MachO != nil:
+		for i, s := range f.MachO.Sections {
+			if s.Seg == "__TEXT" && s.Name == "__text" {
+				return i + 1
+			}
+		}
+	case f.PE != nil:
+		for i, s := range f.PE.Sections {
+			if s.Name == ".text" {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
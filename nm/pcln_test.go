@@ -0,0 +1,86 @@
+package nm
+
+import (
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+func symbolsByName(syms []Symbol) map[string]Symbol {
+	ret := make(map[string]Symbol, len(syms))
+	for _, s := range syms {
+		ret[s.Name] = s
+	}
+	return ret
+}
+
+func TestListSymbolsStripped(t *testing.T) {
+	full, err := ListSymbols(elftest.GoBinary(t, helloProg))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	stripped, err := ListSymbols(elftest.GoBinary(t, helloProg, "-ldflags=-s -w"))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(stripped) == 0 {
+		t.Fatalf("expected symbols from the pclntab")
+	}
+
+	fullByName := symbolsByName(full)
+	for _, s := range stripped {
+		fs, ok := fullByName[s.Name]
+		if !ok || fs.Type != SymbolTypeGlobalText && fs.Type != SymbolTypeText {
+			continue
+		}
+		// the pclntab extent includes the padding up to the next function,
+		// and the ABI wrapper if there is one
+		size, maxPad := fs.Size, int64(64)
+		if wrapper, ok := fullByName[s.Name+".abi0"]; ok {
+			size += wrapper.Size
+			maxPad *= 2
+		}
+		if s.Size < size || s.Size >= size+maxPad {
+			t.Errorf("expected size of %s close to %d, got %d", s.Name, size, s.Size)
+		}
+	}
+	if _, ok := symbolsByName(stripped)["main.main"]; !ok {
+		t.Errorf("expected main.main in the stripped binary")
+	}
+}
+
+func TestListSymbolsStrippedPE(t *testing.T) {
+	t.Setenv("GOOS", "windows")
+	t.Setenv("GOARCH", "amd64")
+	t.Setenv("CGO_ENABLED", "0")
+	syms, err := ListSymbols(elftest.GoBinary(t, helloProg, "-ldflags=-s -w"))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if s, ok := symbolsByName(syms)["main.main"]; !ok || s.Size == 0 {
+		t.Errorf("expected main.main in the stripped binary, got %v", s)
+	}
+}
+
+func TestListSymbolsStrippedMachO(t *testing.T) {
+	t.Setenv("GOOS", "darwin")
+	t.Setenv("GOARCH", "arm64")
+	t.Setenv("CGO_ENABLED", "0")
+	syms, err := ListSymbols(elftest.GoBinary(t, helloProg, "-ldflags=-s -w"))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	// the dyld imports stay in the symbol table of stripped binaries
+	undefined := 0
+	for _, s := range syms {
+		if s.Section == 0 {
+			undefined++
+		}
+	}
+	if undefined == 0 {
+		t.Errorf("expected the undefined imports to be kept")
+	}
+	if s, ok := symbolsByName(syms)["main.main"]; !ok || s.Size == 0 {
+		t.Errorf("expected main.main in the stripped binary, got %v", s)
+	}
+}
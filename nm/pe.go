@@ -0,0 +1,142 @@
+package nm
+
+import (
+	"debug/pe"
+	"sort"
+	"strings"
+)
+
+// COFF storage classes
+const (
+	peClassExternal     = 2
+	peClassStatic       = 3
+	peClassLabel        = 6
+	peClassFunction     = 101
+	peClassFile         = 103
+	peClassSection      = 104
+	peClassWeakExternal = 105
+)
+
+// COFF special section numbers
+const (
+	peSectionUndefined = 0
+	peSectionAbsolute  = -1
+	peSectionDebug     = -2
+)
+
+// COFF relocation types patching 8 byte addresses
+const (
+	peRelAMD64Addr64 = 0x1
+	peRelARM64Addr64 = 0xe
+)
+
+// PESymbols reads the COFF symbol table of a PE file. Like Mach-O, COFF
+// doesn't record symbol sizes, so they are inferred from the distance to the
+// next symbol in the same section. Symbol values are virtual addresses.
+func PESymbols(f *pe.File) ([]Symbol, error) {
+	base := peImageBase(f)
+	ret := make([]Symbol, 0, len(f.Symbols))
+	for _, s := range f.Symbols {
+		switch s.StorageClass {
+		case peClassFile, peClassSection, peClassFunction, peClassLabel:
+			continue
+		}
+		sym := Symbol{
+			Name:    s.Name,
+			Kind:    peUndefinedKind(s),
+			Value:   int64(s.Value),
+			Binding: peBinding(s),
+		}
+		if s.SectionNumber > 0 && int(s.SectionNumber) <= len(f.Sections) {
+			sect := f.Sections[s.SectionNumber-1]
+			// section definition symbols share the name of the section
+			if s.StorageClass == peClassStatic && s.Value == 0 && s.Name == sect.Name {
+				continue
+			}
+			sym.Section = int(s.SectionNumber)
+			sym.Value = base + int64(sect.VirtualAddress) + int64(s.Value)
+			sym.Kind = peKind(sect, s)
+		}
+		sym.Type = symbolType(sym.Kind, sym.Binding)
+		ret = append(ret, sym)
+	}
+
+	ends := make(map[int]int64, len(f.Sections))
+	for i, sect := range f.Sections {
+		ends[i+1] = base + int64(sect.VirtualAddress) + int64(peSectionSize(sect))
+	}
+	inferSizes(ret, ends)
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret, nil
+}
+
+// peImageBase returns the preferred load address of an image, or zero for
+// object files.
+func peImageBase(f *pe.File) int64 {
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		return int64(oh.ImageBase)
+	case *pe.OptionalHeader64:
+		return int64(oh.ImageBase)
+	}
+	return 0
+}
+
+// peSectionSize is the size of the section once loaded. Object files don't
+// have a virtual size, only the raw one.
+func peSectionSize(sect *pe.Section) uint32 {
+	if sect.VirtualSize != 0 {
+		return sect.VirtualSize
+	}
+	return sect.Size
+}
+
+func peBinding(s *pe.Symbol) Binding {
+	switch s.StorageClass {
+	case peClassExternal:
+		return BindingGlobal
+	case peClassWeakExternal:
+		return BindingWeak
+	default:
+		return BindingLocal
+	}
+}
+
+// peUndefinedKind classifies symbols by their special section numbers. An
+// undefined external symbol with a value is a common symbol of that size.
+func peUndefinedKind(s *pe.Symbol) SymbolKind {
+	switch s.SectionNumber {
+	case peSectionUndefined:
+		if s.Value != 0 {
+			return KindCommon
+		}
+		return KindUndefined
+	case peSectionAbsolute:
+		return KindAbsolute
+	case peSectionDebug:
+		return KindDebug
+	}
+	return KindUnknown
+}
+
+// peKind classifies a symbol by the characteristics of its section. Go
+// linked images place .bss after the initialized part of .data, so symbols
+// past the raw data of a section are reported as BSS too.
+func peKind(sect *pe.Section, s *pe.Symbol) SymbolKind {
+	c := sect.Characteristics
+	switch {
+	case sect.Name == ".tls" || strings.HasPrefix(sect.Name, ".tls$"):
+		return KindTLS
+	case c&(pe.IMAGE_SCN_CNT_CODE|pe.IMAGE_SCN_MEM_EXECUTE) != 0:
+		return KindText
+	case c&pe.IMAGE_SCN_CNT_UNINITIALIZED_DATA != 0 || s.Value >= sect.Size:
+		return KindBSS
+	case c&pe.IMAGE_SCN_MEM_WRITE != 0:
+		return KindData
+	default:
+		return KindReadOnlyData
+	}
+}
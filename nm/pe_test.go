@@ -0,0 +1,68 @@
+package nm
+
+import (
+	"debug/pe"
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+func windowsBinary(t *testing.T) string {
+	t.Helper()
+	t.Setenv("GOOS", "windows")
+	t.Setenv("GOARCH", "amd64")
+	t.Setenv("CGO_ENABLED", "0")
+	return elftest.GoBinary(t, helloProg)
+}
+
+func TestPESymbols(t *testing.T) {
+	f, err := pe.Open(windowsBinary(t))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	defer f.Close()
+	syms, err := PESymbols(f)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	text := f.Section(".text")
+	base := int64(f.OptionalHeader.(*pe.OptionalHeader64).ImageBase)
+	start := base + int64(text.VirtualAddress)
+	end := start + int64(text.VirtualSize)
+	found := false
+	for _, s := range syms {
+		if s.Name != "main.main" {
+			continue
+		}
+		found = true
+		if s.Type != SymbolTypeGlobalText {
+			t.Errorf("expected global text, got %s", s.Type)
+		}
+		if s.Size == 0 || s.Value < start || s.Value+s.Size > end {
+			t.Errorf("expected main.main inside .text [%#x,%#x), got %#x+%d", start, end, s.Value, s.Size)
+		}
+	}
+	if !found {
+		t.Errorf("expected to find main.main")
+	}
+}
+
+func TestListImportsPE(t *testing.T) {
+	imps, err := ListImports(windowsBinary(t), "")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	lib, sym := false, false
+	for _, imp := range imps {
+		if imp == (Import{Library: "kernel32.dll"}) {
+			lib = true
+		}
+		if imp == (Import{Library: "kernel32.dll", Name: "ExitProcess"}) {
+			sym = true
+		}
+	}
+	if !lib || !sym {
+		t.Errorf("expected kernel32.dll and its ExitProcess import, got %v", imps)
+	}
+}
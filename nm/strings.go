@@ -0,0 +1,487 @@
+package nm
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"math/bits"
+	"sort"
+
+	"github.com/tzneal/bincmp/internal/objfile"
+)
+
+// String is a string literal of a Go binary.
+type String struct {
+	Value   string
+	Address int64
+}
+
+func (s String) IsEmpty() bool {
+	return s.Value == ""
+}
+
+// ListStrings returns the string literals of a Go binary. The linker lays
+// them out back to back in the go:string.* symbol, go.string.* before Go
+// 1.20, without anything marking where one ends. They are split where the
+// binary refers to them with a length, which also ends the string: string
+// headers in data and, on amd64 and arm64, instructions loading the address
+// and length of a string. Addresses code loads without a length only start
+// a string if they aren't inside one referred to with a length, as they
+// may point into its middle, like the digits of "0123456789abcdef", and
+// would split it differently once the layout changes. Bytes that nothing
+// refers to stay with the string before them.
+// Files without the symbol, such as stripped or non-Go binaries, have no
+// strings.
+func ListStrings(filename, arch string) ([]String, error) {
+	f, err := objfile.Open(filename, arch)
+	if objfile.IsUnknownFormat(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if f.Archive != nil || f.Wasm != nil {
+		return nil, nil
+	}
+	syms, err := symbolTable(f)
+	if err != nil {
+		return nil, err
+	}
+	start, end := stringRegion(syms)
+	if start == 0 {
+		return nil, nil
+	}
+	img := newImage(f)
+	data := img.read(start, end)
+	if data == nil {
+		return nil, nil
+	}
+
+	return splitStrings(data, start, func(ref func(addr, size int64)) {
+		img.dataRefs(ref)
+		img.codeRefs(ref)
+	}), nil
+}
+
+// splitStrings splits the string data found at start where refs, which
+// calls ref with every reference, refers to it. References outside of the
+// data, or extending past its end, are ignored.
+func splitStrings(data []byte, start int64, refs func(ref func(addr, size int64))) []String {
+	end := start + int64(len(data))
+	bounds := map[int64]bool{start: true}
+	// the bytes of strings referred to with a length, other than their
+	// first
+	var inside []span
+	var bare []int64
+	ref := func(addr, size int64) {
+		if addr < start || addr >= end || size < 0 || size > end-addr {
+			return
+		}
+		if size == 0 {
+			bare = append(bare, addr)
+			return
+		}
+		bounds[addr] = true
+		bounds[addr+size] = true
+		if size > 1 {
+			inside = append(inside, span{off: addr + 1, len: size - 1})
+		}
+	}
+	refs(ref)
+	// end is appended below
+	delete(bounds, end)
+	inside = mergeSpans(inside)
+	for _, addr := range bare {
+		if !containsAddr(inside, addr) {
+			bounds[addr] = true
+		}
+	}
+
+	offs := make([]int64, 0, len(bounds))
+	for b := range bounds {
+		offs = append(offs, b)
+	}
+	sort.Slice(offs, func(i, j int) bool { return offs[i] < offs[j] })
+	offs = append(offs, end)
+	var ret []String
+	for i := 0; i < len(offs)-1; i++ {
+		s := data[offs[i]-start : offs[i+1]-start]
+		if i == len(offs)-2 {
+			// the end of the symbol is padded
+			for len(s) > 0 && s[len(s)-1] == 0 {
+				s = s[:len(s)-1]
+			}
+		}
+		if len(s) > 0 {
+			ret = append(ret, String{Value: string(s), Address: offs[i]})
+		}
+	}
+	return ret
+}
+
+// mergeSpans sorts spans and merges those that overlap or touch.
+func mergeSpans(spans []span) []span {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].off < spans[j].off })
+	var ret []span
+	for _, s := range spans {
+		if n := len(ret); n > 0 && s.off <= ret[n-1].off+ret[n-1].len {
+			if e := s.off + s.len; e > ret[n-1].off+ret[n-1].len {
+				ret[n-1].len = e - ret[n-1].off
+			}
+			continue
+		}
+		ret = append(ret, s)
+	}
+	return ret
+}
+
+// containsAddr reports whether addr is in one of spans, as returned by
+// mergeSpans.
+func containsAddr(spans []span, addr int64) bool {
+	i := sort.Search(len(spans), func(i int) bool {
+		return spans[i].off+spans[i].len > addr
+	})
+	return i < len(spans) && spans[i].off <= addr
+}
+
+// stringRegion returns the addresses of the string data of a Go binary,
+// from the go:string.* symbol to the next symbol, or zeros if it has none.
+func stringRegion(syms []Symbol) (start, end int64) {
+	for _, s := range syms {
+		if (s.Name == "go:string.*" || s.Name == "go.string.*") && s.Section != 0 {
+			start = s.Value
+		}
+	}
+	if start == 0 {
+		return 0, 0
+	}
+	for _, s := range syms {
+		if s.Section != 0 && s.Value > start && (end == 0 || s.Value < end) {
+			end = s.Value
+		}
+	}
+	if end == 0 {
+		return 0, 0
+	}
+	return start, end
+}
+
+// imageSection is a section of a linked binary, by the address it's
+// loaded at.
+type imageSection struct {
+	addr, size int64
+	code       bool
+	data       func() ([]byte, error)
+}
+
+// image reads the sections of a linked binary by address.
+type image struct {
+	sects   []imageSection
+	order   binary.ByteOrder
+	ptrSize int
+	amd64   bool
+	arm64   bool
+	// relas are the pointers of position independent ELF executables by
+	// their address, which are relocated instead of stored
+	relas map[int64]int64
+	// cache holds the contents of the sections read so far
+	cache map[int][]byte
+}
+
+func newImage(f *objfile.File) *image {
+	img := &image{order: binary.LittleEndian, ptrSize: 8, cache: map[int][]byte{}}
+	switch {
+	case f.ELF != nil:
+		img.order = f.ELF.ByteOrder
+		if f.ELF.Class == elf.ELFCLASS32 {
+			img.ptrSize = 4
+		}
+		img.amd64 = f.ELF.Machine == elf.EM_X86_64
+		img.arm64 = f.ELF.Machine == elf.EM_AARCH64
+		for _, s := range f.ELF.Sections {
+			if s.Flags&elf.SHF_ALLOC == 0 || s.Type == elf.SHT_NOBITS {
+				continue
+			}
+			img.sects = append(img.sects, imageSection{addr: int64(s.Addr), size: int64(s.Size),
+				code: s.Flags&elf.SHF_EXECINSTR != 0, data: s.Data})
+		}
+		if f.ELF.Type == elf.ET_DYN {
+			img.relas = elfRelativeRelocs(f.ELF)
+		}
+	case f.MachO != nil:
+		img.order = f.MachO.ByteOrder
+		if f.MachO.Magic == macho.Magic32 {
+			img.ptrSize = 4
+		}
+		img.amd64 = f.MachO.Cpu == macho.CpuAmd64
+		img.arm64 = f.MachO.Cpu == macho.CpuArm64
+		for _, s := range f.MachO.Sections {
+			if machoIsZerofill(s) {
+				continue
+			}
+			img.sects = append(img.sects, imageSection{addr: int64(s.Addr), size: int64(s.Size),
+				code: s.Flags&machoAttrInstructions != 0, data: s.Data})
+		}
+	case f.PE != nil:
+		if _, ok := f.PE.OptionalHeader.(*pe.OptionalHeader32); ok {
+			img.ptrSize = 4
+		}
+		img.amd64 = f.PE.Machine == pe.IMAGE_FILE_MACHINE_AMD64
+		img.arm64 = f.PE.Machine == pe.IMAGE_FILE_MACHINE_ARM64
+		base := peImageBase(f.PE)
+		for _, s := range f.PE.Sections {
+			if s.Characteristics&pe.IMAGE_SCN_CNT_UNINITIALIZED_DATA != 0 {
+				continue
+			}
+			img.sects = append(img.sects, imageSection{addr: base + int64(s.VirtualAddress), size: int64(s.VirtualSize),
+				code: s.Characteristics&pe.IMAGE_SCN_CNT_CODE != 0, data: s.Data})
+		}
+	}
+	return img
+}
+
+// machoAttrInstructions are the section attributes marking code,
+// S_ATTR_PURE_INSTRUCTIONS and S_ATTR_SOME_INSTRUCTIONS.
+const machoAttrInstructions = 0x80000400
+
+// read returns the bytes from start to end, or nil if they aren't all in
+// one section.
+func (img *image) read(start, end int64) []byte {
+	for i, s := range img.sects {
+		if start < s.addr || end > s.addr+s.size {
+			continue
+		}
+		data, err := img.sectionData(i)
+		if err != nil || end-s.addr > int64(len(data)) {
+			return nil
+		}
+		return data[start-s.addr : end-s.addr]
+	}
+	return nil
+}
+
+// sectionData returns the contents of the i'th section.
+func (img *image) sectionData(i int) ([]byte, error) {
+	if data, ok := img.cache[i]; ok {
+		return data, nil
+	}
+	data, err := img.sects[i].data()
+	if err != nil {
+		return nil, err
+	}
+	img.cache[i] = data
+	return data, nil
+}
+
+// ptr decodes the pointer sized word at the start of b, which is stored at
+// addr in the binary.
+func (img *image) ptr(b []byte, addr int64) int64 {
+	if addend, ok := img.relas[addr]; ok {
+		return addend
+	}
+	return img.word(b)
+}
+
+// word decodes the pointer sized word at the start of b.
+func (img *image) word(b []byte) int64 {
+	if img.ptrSize == 4 {
+		return int64(img.order.Uint32(b))
+	}
+	return int64(img.order.Uint64(b))
+}
+
+// words returns n pointer sized words at addr, or nil if they can't be
+// read.
+func (img *image) words(addr int64, n int) []int64 {
+	data := img.read(addr, addr+int64(n*img.ptrSize))
+	if data == nil {
+		return nil
+	}
+	ret := make([]int64, n)
+	for i := range ret {
+		off := i * img.ptrSize
+		ret[i] = img.ptr(data[off:], addr+int64(off))
+	}
+	return ret
+}
+
+// dataRefs calls ref with the pointer and length of every string header in
+// the data sections, that is every aligned pointer followed by a length.
+// Position independent ELF executables keep their pointers in relocations.
+func (img *image) dataRefs(ref func(addr, size int64)) {
+	n := int64(img.ptrSize)
+	for i, s := range img.sects {
+		if s.code {
+			continue
+		}
+		data, err := img.sectionData(i)
+		if err != nil {
+			continue
+		}
+		for off := int64(0); off+2*n <= int64(len(data)); off += n {
+			addr := img.ptr(data[off:], s.addr+off)
+			if size := img.word(data[off+n:]); addr != 0 && size > 0 {
+				ref(addr, size)
+			}
+		}
+	}
+}
+
+// codeRefs calls ref with the addresses code loads: the targets of
+// RIP-relative LEAQ instructions on amd64 and of ADRP+ADD pairs on arm64.
+// When the next instruction moves an immediate into the register of the
+// next argument, as code passing a string does with its length, that is
+// the size of the reference; otherwise it's 0.
+func (img *image) codeRefs(ref func(addr, size int64)) {
+	if !img.amd64 && !img.arm64 {
+		return
+	}
+	for si, s := range img.sects {
+		if !s.code {
+			continue
+		}
+		data, err := img.sectionData(si)
+		if err != nil {
+			continue
+		}
+		if img.amd64 {
+			// REX.W 8D /r with a RIP-relative ModRM byte and a 32 bit
+			// displacement from the next instruction
+			for i := 0; i+7 <= len(data); i++ {
+				if data[i]&0xf8 != 0x48 || data[i+1] != 0x8d || data[i+2]&0xc7 != 0x05 {
+					continue
+				}
+				reg := int(data[i]&4)<<1 | int(data[i+2]>>3&7)
+				size := amd64MovImm(data[i+7:], amd64NextArg[reg])
+				disp := int32(binary.LittleEndian.Uint32(data[i+3:]))
+				ref(s.addr+int64(i)+7+int64(disp), size)
+			}
+			continue
+		}
+		for i := 0; i+8 <= len(data); i += 4 {
+			adrp := img.order.Uint32(data[i:])
+			add := img.order.Uint32(data[i+4:])
+			// ADRP Xd, page followed by ADD Xd, Xd, #imm without shift
+			if adrp&0x9f000000 != 0x90000000 || add&0xffc00000 != 0x91000000 ||
+				adrp&0x1f != add&0x1f || (add>>5)&0x1f != adrp&0x1f {
+				continue
+			}
+			var size int64
+			if i+12 <= len(data) {
+				size = arm64MovImm(img.order.Uint32(data[i+8:]), adrp&0x1f+1)
+			}
+			imm := int64(adrp>>29&3 | (adrp>>5&0x7ffff)<<2)
+			imm = imm << 43 >> 31 // sign extend 21 bits, times 4096
+			page := (s.addr + int64(i)) &^ 0xfff
+			ref(page+imm+int64(add>>10&0xfff), size)
+		}
+	}
+}
+
+// amd64NextArg maps the integer argument registers of the Go register ABI,
+// AX, BX, CX, DI, SI and R8 to R11, to the register of the next argument.
+// Other registers map to -1.
+var amd64NextArg = [16]int{0: 3, 3: 1, 1: 7, 7: 6, 6: 8, 8: 9, 9: 10, 10: 11,
+	2: -1, 4: -1, 5: -1, 11: -1, 12: -1, 13: -1, 14: -1, 15: -1}
+
+// amd64MovImm decodes a MOVL $imm, reg at the start of b, B8+r with an
+// optional REX.B prefix, and returns the immediate, or 0 if b doesn't
+// start with one or it isn't positive.
+func amd64MovImm(b []byte, reg int) int64 {
+	if reg < 0 {
+		return 0
+	}
+	if reg >= 8 {
+		if len(b) == 0 || b[0] != 0x41 {
+			return 0
+		}
+		b, reg = b[1:], reg-8
+	}
+	if len(b) < 5 || b[0] != 0xb8+byte(reg) {
+		return 0
+	}
+	if imm := int64(int32(binary.LittleEndian.Uint32(b[1:]))); imm > 0 {
+		return imm
+	}
+	return 0
+}
+
+// arm64MovImm decodes a move of an immediate to register reg, either a MOVZ
+// or an ORR of a bitmask immediate with the zero register, and returns the
+// immediate, or 0 if insn isn't one or it isn't positive.
+func arm64MovImm(insn, reg uint32) int64 {
+	if insn&0x1f != reg {
+		return 0
+	}
+	switch {
+	case insn&0x7f800000 == 0x52800000:
+		// MOVZ, shifted by hw*16
+		if insn>>31 == 1 || insn>>21&3 < 2 {
+			return int64(insn>>5&0xffff) << (insn >> 21 & 3 * 16)
+		}
+	case insn&0x7f8003e0 == 0x320003e0:
+		// ORR Rd, ZR, #imm
+		imm, ok := arm64BitMask(insn>>22&1, insn>>16&0x3f, insn>>10&0x3f, insn>>31 == 1)
+		if ok && int64(imm) > 0 {
+			return int64(imm)
+		}
+	}
+	return 0
+}
+
+// arm64BitMask decodes the logical immediate with the fields n, immr and
+// imms, as DecodeBitMasks in the Arm architecture reference manual does: a
+// run of ones rotated right within an element of 2 to 64 bits, repeated.
+func arm64BitMask(n, immr, imms uint32, is64 bool) (uint64, bool) {
+	combined := n<<6 | ^imms&0x3f
+	if combined == 0 || !is64 && n == 1 {
+		return 0, false
+	}
+	length := uint32(31 - bits.LeadingZeros32(combined))
+	if length < 1 {
+		return 0, false
+	}
+	size := uint32(1) << length
+	levels := size - 1
+	ones, rot := imms&levels, immr&levels
+	if ones == levels {
+		return 0, false
+	}
+	elem := uint64(1)<<(ones+1) - 1
+	if rot > 0 {
+		elem = (elem>>rot | elem<<(size-rot)) & (uint64(1)<<size - 1)
+	}
+	width := uint32(64)
+	if !is64 {
+		width = 32
+	}
+	var imm uint64
+	for i := uint32(0); i < width; i += size {
+		imm |= elem << i
+	}
+	return imm, true
+}
+
+// elfRelativeRelocs returns the dynamic relocations of f with addends, which
+// hold the pointers of position independent executables.
+func elfRelativeRelocs(f *elf.File) map[int64]int64 {
+	if f.Class != elf.ELFCLASS64 {
+		return nil
+	}
+	ret := map[int64]int64{}
+	for _, s := range f.Sections {
+		if s.Type != elf.SHT_RELA || s.Flags&elf.SHF_ALLOC == 0 {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			continue
+		}
+		for off := 0; off+24 <= len(data); off += 24 {
+			ret[int64(f.ByteOrder.Uint64(data[off:]))] = int64(f.ByteOrder.Uint64(data[off+16:]))
+		}
+	}
+	return ret
+}
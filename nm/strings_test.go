@@ -0,0 +1,212 @@
+package nm
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+const stringsProg = `package main
+
+import "os"
+
+// referenced by the string header of the slice
+var table = []string{"first table entry", "second table entry"}
+
+func main() {
+	// referenced by the code loading its address
+	os.Stdout.WriteString("a help text written by main\n")
+	for _, s := range table {
+		os.Stdout.WriteString(s)
+	}
+}
+`
+
+func TestListStrings(t *testing.T) {
+	tcs := []struct {
+		name string
+		env  map[string]string
+		args []string
+	}{
+		{name: "amd64"},
+		{name: "pie", args: []string{"-buildmode=pie"}},
+		{name: "arm64", env: map[string]string{"GOARCH": "arm64"}},
+		{name: "pe", env: map[string]string{"GOOS": "windows", "GOARCH": "amd64"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("CGO_ENABLED", "0")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			strs, err := ListStrings(elftest.GoBinary(t, stringsProg, tc.args...), "")
+			if err != nil {
+				t.Fatalf("expected no error, got %s", err)
+			}
+			found := map[string]bool{}
+			for _, s := range strs {
+				found[s.Value] = true
+			}
+			for _, exp := range []string{"a help text written by main\n", "first table entry", "second table entry"} {
+				if !found[exp] {
+					t.Errorf("expected string %q", exp)
+				}
+			}
+		})
+	}
+}
+
+func TestListStringsC(t *testing.T) {
+	strs, err := ListStrings(elftest.CBinary(t, "int main() { return 0; }"), "")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(strs) != 0 {
+		t.Errorf("expected no strings, got %d", len(strs))
+	}
+}
+
+const neighbourProg = `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	fmt.Fprintln(os.Stderr, "usage: tool [flags] file")
+	fmt.Fprintln(os.Stderr, %q)
+	fmt.Fprintln(os.Stderr, "report bugs to the issue tracker")
+	fmt.Println(len(os.Args) > 2)
+}
+`
+
+func TestListStringsNeighbour(t *testing.T) {
+	for _, goarch := range []string{"amd64", "arm64"} {
+		t.Run(goarch, func(t *testing.T) {
+			t.Setenv("GOARCH", goarch)
+			t.Setenv("CGO_ENABLED", "0")
+			list := func(help string) map[string]bool {
+				strs, err := ListStrings(elftest.GoBinary(t, fmt.Sprintf(neighbourProg, help)), "")
+				if err != nil {
+					t.Fatalf("expected no error, got %s", err)
+				}
+				ret := map[string]bool{}
+				for _, s := range strs {
+					ret[s.Value] = true
+				}
+				return ret
+			}
+			// a longer string moves the strings after it
+			a := list("  -v  print more")
+			b := list("  -v  print more output while working")
+			var removed, added []string
+			for s := range a {
+				if !b[s] {
+					removed = append(removed, s)
+				}
+			}
+			for s := range b {
+				if !a[s] {
+					added = append(added, s)
+				}
+			}
+			if len(removed) != 1 || removed[0] != "  -v  print more" {
+				t.Errorf("expected only the old help text removed, got %q", removed)
+			}
+			if len(added) != 1 || added[0] != "  -v  print more output while working" {
+				t.Errorf("expected only the new help text added, got %q", added)
+			}
+		})
+	}
+}
+
+func TestAMD64MovImm(t *testing.T) {
+	for _, tc := range []struct {
+		b   []byte
+		reg int
+		exp int64
+	}{
+		// MOVL $0x25, BX
+		{[]byte{0xbb, 0x25, 0, 0, 0}, 3, 0x25},
+		// MOVL $0x25, R9
+		{[]byte{0x41, 0xb9, 0x25, 0, 0, 0}, 9, 0x25},
+		// into another register
+		{[]byte{0xb9, 0x25, 0, 0, 0}, 3, 0},
+		{[]byte{0xbb, 0xff, 0xff, 0xff, 0xff}, 3, 0},
+		{[]byte{0xbb, 0x25}, 3, 0},
+	} {
+		if got := amd64MovImm(tc.b, tc.reg); got != tc.exp {
+			t.Errorf("%x: expected %#x, got %#x", tc.b, tc.exp, got)
+		}
+	}
+}
+
+func TestARM64MovImm(t *testing.T) {
+	for _, tc := range []struct {
+		insn, reg uint32
+		exp       int64
+	}{
+		// MOVD $37, R1
+		{0xd28004a1, 1, 37},
+		// MOVZ with a shift of 16
+		{0x52a00021, 1, 0x10000},
+		// ORR $7, ZR, R1
+		{0xb2400be1, 1, 7},
+		// ORRW $65536, ZR, R1
+		{0x321003e1, 1, 0x10000},
+		// ORR $0xff0, ZR, R2
+		{0xb27c1fe2, 2, 0xff0},
+		// MOVW $1431655765, R1
+		{0x3200f3e1, 1, 0x55555555},
+		// into another register
+		{0xd28004a1, 2, 0},
+		// ADD $1, R1, R1
+		{0x91000421, 1, 0},
+	} {
+		if got := arm64MovImm(tc.insn, tc.reg); got != tc.exp {
+			t.Errorf("%08x: expected %#x, got %#x", tc.insn, tc.exp, got)
+		}
+	}
+}
+
+func TestSplitStrings(t *testing.T) {
+	const start = 0x1000
+	data := []byte("0123456789abcdefusage: toolhelp\x00\x00\x00")
+	refs := func(ref func(addr, size int64)) {
+		// the digits, and a bare reference into them
+		ref(start, 16)
+		ref(start+10, 0)
+		// a string loaded without a length
+		ref(start+16, 0)
+		ref(start+23, 4)
+		// lengths read from data that isn't a string header
+		ref(start+27, math.MaxInt64)
+		ref(start+27, -1)
+		ref(start+0x2000, 1)
+	}
+	exp := []String{
+		{Value: "0123456789abcdef", Address: start},
+		{Value: "usage: ", Address: start + 16},
+		{Value: "tool", Address: start + 23},
+		{Value: "help", Address: start + 27},
+	}
+	if got := splitStrings(data, start, refs); !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected %q, got %q", exp, got)
+	}
+}
+
+func TestContainsAddr(t *testing.T) {
+	spans := mergeSpans([]span{{off: 30, len: 5}, {off: 10, len: 5}, {off: 12, len: 10}, {off: 22, len: 2}})
+	if exp := []span{{off: 10, len: 14}, {off: 30, len: 5}}; !reflect.DeepEqual(spans, exp) {
+		t.Errorf("expected %v, got %v", exp, spans)
+	}
+	for addr, exp := range map[int64]bool{9: false, 10: true, 23: true, 24: false, 34: true, 35: false} {
+		if got := containsAddr(spans, addr); got != exp {
+			t.Errorf("%d: expected %v, got %v", addr, exp, got)
+		}
+	}
+}
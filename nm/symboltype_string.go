@@ -4,9 +4,9 @@ package nm
 
 import "fmt"
 
-const _SymbolType_name = "SymbolTypeUnknownSymbolTypeBSSSymbolTypeGlobalBSSSymbolTypeDataSymbolTypeGlobalDataSymbolTypeTextSymbolTypeGlobalTextSymbolTypeReadOnlyDataSymbolTypeGlobalReadOnlyData"
+const _SymbolType_name = "SymbolTypeUnknownSymbolTypeBSSSymbolTypeGlobalBSSSymbolTypeDataSymbolTypeGlobalDataSymbolTypeTextSymbolTypeGlobalTextSymbolTypeReadOnlyDataSymbolTypeGlobalReadOnlyDataSymbolTypeUndefinedSymbolTypeWeakSymbolTypeWeakUndefinedSymbolTypeWeakObjectSymbolTypeWeakObjectUndefinedSymbolTypeAbsoluteSymbolTypeGlobalAbsoluteSymbolTypeCommonSymbolTypeGlobalCommonSymbolTypeUniqueGlobalSymbolTypeIndirectFunctionSymbolTypeDebug"
 
-var _SymbolType_index = [...]uint8{0, 17, 30, 49, 63, 83, 97, 117, 139, 167}
+var _SymbolType_index = [...]uint16{0, 17, 30, 49, 63, 83, 97, 117, 139, 167, 186, 200, 223, 243, 272, 290, 314, 330, 352, 374, 400, 415}
 
 func (i SymbolType) String() string {
 	if i >= SymbolType(len(_SymbolType_index)-1) {
@@ -0,0 +1,48 @@
+package nm
+
+import (
+	"sort"
+
+	"github.com/tzneal/bincmp/wasm"
+)
+
+// WasmSymbols returns the function bodies of the code section as symbols,
+// named after the module's name section. Values are file offsets, as
+// WebAssembly code has no addresses.
+func WasmSymbols(m *wasm.Module) []Symbol {
+	var codeIdx int
+	for i, s := range m.Sections {
+		if s.ID == wasm.SectionCode {
+			codeIdx = i + 1
+		}
+	}
+
+	exported := map[uint32]bool{}
+	for _, e := range m.Exports {
+		if e.Kind == wasm.KindFunc {
+			exported[e.Index] = true
+		}
+	}
+
+	ret := make([]Symbol, 0, len(m.Functions))
+	for _, fn := range m.Functions {
+		sym := Symbol{
+			Name:    fn.Name,
+			Type:    SymbolTypeText,
+			Kind:    KindText,
+			Size:    fn.Size,
+			Value:   fn.Offset,
+			Section: codeIdx,
+			Binding: BindingLocal,
+		}
+		if exported[fn.Index] {
+			sym.Type = SymbolTypeGlobalText
+			sym.Binding = BindingGlobal
+		}
+		ret = append(ret, sym)
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
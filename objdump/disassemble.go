@@ -22,6 +22,9 @@ type Disasm struct {
 	Offset int64
 	Bin    string
 	Asm    string
+	// Norm is the instruction with the addresses it refers to replaced by
+	// symbols, set by Normalize.
+	Norm string
 }
 
 func (f Function) IsEmpty() bool {
@@ -78,9 +81,10 @@ func parseDisassembly(r io.Reader) ([]Function, error) {
 	scanner := bufio.NewScanner(r)
 
 	//TEXT strings.EqualFold(SB) /home/todd/Projects/go/src/strings/strings.go
-	fnRe := regexp.MustCompile(`^TEXT ([^(]+)\(SB\) (.*)$`)
+	//TEXT net/http.(*Server).Serve(SB) /usr/local/go/src/net/http/server.go
+	fnRe := regexp.MustCompile(`^TEXT (.+)\(SB\) (.*)$`)
 	//		tables.go:128   0x5997a0        4883ec30                SUBQ $0x30, SP
-	asmRe := regexp.MustCompile(`\s+([^:]*):(\d*)\s+(0x[[:xdigit:]]+)\s+([[:xdigit:]]+)\s+(.*)$`)
+	asmRe := regexp.MustCompile(`\s+([^:]*):(-?\d*)\s+(0x[[:xdigit:]]+)\s+([[:xdigit:]]+)\s+(.*)$`)
 	curFn := Function{}
 	ret := []Function{}
 	for scanner.Scan() {
@@ -113,6 +117,9 @@ func parseDisassembly(r io.Reader) ([]Function, error) {
 		}
 		// disassembly of an existing function
 		fields := asmRe.FindStringSubmatch(line)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("unable to parse instruction from %s", line)
+		}
 		file := fields[1]
 		lineNo := parseInt(fields[2], 10)
 		off := parseInt(fields[3][2:], 16)
@@ -64,3 +64,27 @@ TEXT vendor/golang_org/x/net/http2/hpack.pair(SB) /home/todd/Projects/go/src/ven
 		t.Errorf("expected %v, got %v", exp, lastInsn)
 	}
 }
+
+func TestParseDisassemblyMethod(t *testing.T) {
+	inp := `
+TEXT internal/poll.(*FD).Close(SB) /usr/lib/go/src/internal/poll/fd_unix.go
+  fd_unix.go:77		0x4b8f00		493b6610		CMPQ SP, 0x10(R14)
+  :-1			0x4b8f04		7659			JBE 0x4b8f5f
+`
+	fns, err := parseDisassembly(strings.NewReader(inp))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(fns) != 1 {
+		t.Fatalf("expected one function, got %d", len(fns))
+	}
+	if exp := "internal/poll.(*FD).Close"; fns[0].Name != exp {
+		t.Errorf("expected %s, got %s", exp, fns[0].Name)
+	}
+	if len(fns[0].Asm) != 2 {
+		t.Fatalf("expected 2 instructions, got %d", len(fns[0].Asm))
+	}
+	if exp := (Disasm{File: "", Line: -1, Offset: 0x4b8f04, Bin: "7659", Asm: "JBE 0x4b8f5f"}); fns[0].Asm[1] != exp {
+		t.Errorf("expected %v, got %v", exp, fns[0].Asm[1])
+	}
+}
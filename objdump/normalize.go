@@ -0,0 +1,298 @@
+package objdump
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Lookup returns the name of the symbol containing addr and the offset of
+// addr in it.
+type Lookup func(addr int64) (name string, off int64, ok bool)
+
+var (
+	// JBE 0x49a68b, CALL 0x4591d4, LOCK LOOPNE 0x4c08ea
+	targetRe = regexp.MustCompile(`^((?:[A-Z]+ )*[A-Z][A-Z0-9.]*) 0x([[:xdigit:]]+)$`)
+	// ADRP 1134592(PC), R27
+	adrpRe = regexp.MustCompile(`^ADRP -?\d+\(PC\), (R\d+)$`)
+	// MOVD 1568(R27), R26 or LDP (R27), (R3, R4)
+	memRe = regexp.MustCompile(`(?:^|[ (])(-?\d*)\((R\d+)\)`)
+)
+
+// Normalize sets the Norm of every instruction of fn, so that code that
+// only moved, or refers to code and data that moved, compares equal. Branch
+// and call targets become symbol+offset, as in "JBE main.main+0x6b", and
+// PC-relative references to data become the symbol they refer to, as in
+// "LEAQ go:string.*(SB), AX", without an offset, as data is often laid out
+// in a few large symbols. The references are found in the instruction
+// bytes of arch: RIP-relative operands on amd64, and ADRP instructions with
+// the ADD, load or store completing the address on arm64. Other addresses
+// lookup doesn't know are kept.
+func (fn *Function) Normalize(arch string, lookup Lookup) {
+	for i := range fn.Asm {
+		d := &fn.Asm[i]
+		d.Norm = normalizeTarget(*d, lookup)
+		if arch == "amd64" {
+			if norm, ok := normalizeRIP(*d, lookup); ok {
+				d.Norm = norm
+			}
+		}
+	}
+	if arch == "arm64" {
+		for i := 0; i+1 < len(fn.Asm); i++ {
+			if adrp, next, ok := normalizeADRP(fn.Asm[i], fn.Asm[i+1], lookup); ok {
+				fn.Asm[i].Norm, fn.Asm[i+1].Norm = adrp, next
+				i++
+			}
+		}
+	}
+}
+
+// normalizeTarget replaces the target address of a branch or call. Targets
+// outside of the binary, as found when data in the middle of hand written
+// assembly is disassembled, become relative to the instruction, as in
+// "CALL .+0x7b43c41e".
+func normalizeTarget(d Disasm, lookup Lookup) string {
+	m := targetRe.FindStringSubmatch(d.Asm)
+	if m == nil {
+		return ""
+	}
+	// addresses below 0 wrap around
+	v, _ := strconv.ParseUint(m[2], 16, 64)
+	addr := int64(v)
+	if name, off, ok := lookup(addr); ok && off == 0 {
+		return m[1] + " " + name
+	} else if ok {
+		return fmt.Sprintf("%s %s+0x%x", m[1], name, off)
+	}
+	return fmt.Sprintf("%s .%+#x", m[1], addr-d.Offset)
+}
+
+// normalizeRIP replaces the RIP-relative operand of an amd64 instruction.
+// objdump prints its displacement as "0x83cbc(IP)", or without the "(IP)"
+// for VEX and EVEX encoded instructions, as in "VMOVDQA 0x83cbc, X7".
+func normalizeRIP(d Disasm, lookup Lookup) (string, bool) {
+	bin, err := hex.DecodeString(d.Bin)
+	if err != nil {
+		return "", false
+	}
+	disp, ok := ripDisp(bin)
+	if !ok {
+		return "", false
+	}
+	// the displacement is relative to the next instruction
+	name, _, ok := lookup(d.Offset + int64(len(bin)) + disp)
+	if !ok {
+		return "", false
+	}
+	operand := fmt.Sprintf("%#x", disp)
+	if disp < 0 {
+		operand = fmt.Sprintf("-%#x", -disp)
+	}
+	ops := strings.SplitAfterN(d.Asm, " ", 2)
+	if len(ops) != 2 {
+		return "", false
+	}
+	args := strings.Split(ops[1], ", ")
+	for i, arg := range args {
+		if arg == operand || arg == operand+"(IP)" {
+			args[i] = name + "(SB)"
+			return ops[0] + strings.Join(args, ", "), true
+		}
+	}
+	return "", false
+}
+
+// ripDisp decodes an amd64 instruction far enough to find whether it has a
+// RIP-relative memory operand, a ModRM byte with mod 00 and r/m 101, and
+// returns the 32 bit displacement following it.
+func ripDisp(b []byte) (int64, bool) {
+	i := 0
+	// legacy prefixes
+	for i < len(b) && strings.IndexByte("\x66\x67\xf0\xf2\xf3\x2e\x36\x3e\x26\x64\x65", b[i]) >= 0 {
+		i++
+	}
+	if i < len(b) && b[i]&0xf0 == 0x40 {
+		// REX
+		i++
+	}
+	if i >= len(b) {
+		return 0, false
+	}
+	switch b[i] {
+	case 0xc5:
+		// 2 byte VEX, then the opcode
+		i += 3
+	case 0xc4:
+		// 3 byte VEX
+		i += 4
+	case 0x62:
+		// EVEX
+		i += 5
+	case 0x0f:
+		i++
+		if i >= len(b) {
+			return 0, false
+		}
+		switch op := b[i]; {
+		case op == 0x38 || op == 0x3a:
+			i += 2
+		case !twoByteModRM(op):
+			return 0, false
+		default:
+			i++
+		}
+	default:
+		if !oneByteModRM(b[i]) {
+			return 0, false
+		}
+		i++
+	}
+	if i+5 > len(b) || b[i]&0xc7 != 0x05 {
+		return 0, false
+	}
+	return int64(int32(binary.LittleEndian.Uint32(b[i+1:]))), true
+}
+
+// oneByteModRM reports whether a one byte opcode is followed by a ModRM
+// byte in 64 bit mode.
+func oneByteModRM(op byte) bool {
+	switch {
+	case op < 0x40:
+		// ADD, OR, ADC, SBB, AND, SUB, XOR and CMP with a memory operand
+		return op&0x07 < 4
+	case op == 0x63, op == 0x69, op == 0x6b:
+		return true
+	case op >= 0x80 && op <= 0x8f:
+		return true
+	case op == 0xc0, op == 0xc1, op == 0xc6, op == 0xc7:
+		return true
+	case op >= 0xd0 && op <= 0xd3, op >= 0xd8 && op <= 0xdf:
+		return true
+	case op == 0xf6, op == 0xf7, op == 0xfe, op == 0xff:
+		return true
+	}
+	return false
+}
+
+// twoByteModRM reports whether an opcode following 0x0f is followed by a
+// ModRM byte.
+func twoByteModRM(op byte) bool {
+	switch {
+	case op >= 0x05 && op <= 0x09, op == 0x0b, op == 0x0e:
+		// SYSCALL, CLTS, SYSRET, INVD, WBINVD, UD2, FEMMS
+		return false
+	case op >= 0x30 && op <= 0x37, op == 0x77:
+		// WRMSR, RDTSC, RDMSR, RDPMC, SYSENTER, SYSEXIT, GETSEC, EMMS
+		return false
+	case op >= 0x80 && op <= 0x8f:
+		// Jcc rel32
+		return false
+	case op == 0xa0, op == 0xa1, op == 0xa2, op == 0xa8, op == 0xa9, op == 0xaa:
+		// PUSH and POP of FS and GS, CPUID, RSM
+		return false
+	case op >= 0xc8 && op <= 0xcf:
+		// BSWAP
+		return false
+	}
+	return true
+}
+
+// normalizeADRP replaces the page of an arm64 ADRP instruction, and the low
+// 12 bits of the address in the instruction after it using the same
+// register, like "ADD $2304, R27, R27" or "MOVD 1568(R27), R26", with the
+// symbol they address.
+func normalizeADRP(adrp, next Disasm, lookup Lookup) (string, string, bool) {
+	m := adrpRe.FindStringSubmatch(adrp.Asm)
+	if m == nil {
+		return "", "", false
+	}
+	word, err := strconv.ParseUint(adrp.Bin, 16, 32)
+	if err != nil || word&0x9f000000 != 0x90000000 {
+		return "", "", false
+	}
+	imm := int64(word>>29&3 | (word>>5&0x7ffff)<<2)
+	imm = imm << 43 >> 31 // sign extend 21 bits, times 4096
+	page := adrp.Offset&^0xfff + imm
+
+	reg := m[1]
+	var low int64
+	var norm func(name string) string
+	if add := strings.TrimPrefix(next.Asm, "ADD $"); add != next.Asm {
+		args := strings.Split(add, ", ")
+		if len(args) != 3 || args[1] != reg {
+			return "", "", false
+		}
+		v, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return "", "", false
+		}
+		low = v
+		norm = func(name string) string {
+			return "ADD $" + name + "(SB), " + args[1] + ", " + args[2]
+		}
+	} else {
+		var mem []int
+		for _, m := range memRe.FindAllStringSubmatchIndex(next.Asm, -1) {
+			if next.Asm[m[4]:m[5]] == reg {
+				mem = m
+				break
+			}
+		}
+		if mem == nil {
+			return "", "", false
+		}
+		low = parseInt(next.Asm[mem[2]:mem[3]], 10)
+		norm = func(name string) string {
+			return next.Asm[:mem[2]] + name + "(SB)" + next.Asm[mem[1]:]
+		}
+	}
+	name, _, ok := lookup(page + low)
+	if !ok {
+		return "", "", false
+	}
+	return "ADRP " + name + "(SB), " + reg, norm(name), true
+}
+
+// Code returns the normalized instruction if Normalize was called, else the
+// instruction as disassembled.
+func (d Disasm) Code() string {
+	if d.Norm != "" {
+		return d.Norm
+	}
+	return d.Asm
+}
+
+// SameCode reports whether two functions have the same instructions, once
+// normalized if Normalize was called on them. Branches of each function to
+// itself are compared by offset, so that a function that was renamed or
+// moved to another package compares equal to the original.
+func SameCode(fnA, fnB Function) bool {
+	if len(fnA.Asm) != len(fnB.Asm) {
+		return false
+	}
+	for i := range fnA.Asm {
+		codeA, codeB := fnA.Asm[i].Code(), fnB.Asm[i].Code()
+		if codeA != codeB && selfRelative(codeA, fnA.Name) != selfRelative(codeB, fnB.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// selfRelative replaces the target of a branch to the function name, as in
+// "JBE main.main+0x6b" or "JMP main.main(SB)", with ".", as in "JBE .+0x6b".
+func selfRelative(code, name string) string {
+	i := strings.LastIndex(code, " "+name)
+	if i < 0 || name == "" {
+		return code
+	}
+	rest := code[i+1+len(name):]
+	if rest != "" && !strings.HasPrefix(rest, "+0x") && !strings.HasPrefix(rest, "(SB)") {
+		return code
+	}
+	return code[:i] + " ." + rest
+}
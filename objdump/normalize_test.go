@@ -0,0 +1,158 @@
+package objdump
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	syms := map[int64]string{0x401000: "main.main", 0x402000: "runtime.morestack", 0x4a0000: "go:string.*"}
+	lookup := func(addr int64) (string, int64, bool) {
+		for start, name := range syms {
+			if addr >= start && addr < start+0x1000 {
+				return name, addr - start, true
+			}
+		}
+		return "", 0, false
+	}
+	tcs := []struct {
+		insn Disasm
+		exp  string
+	}{
+		{Disasm{Offset: 0x401010, Bin: "7659", Asm: "JBE 0x40106b"}, "JBE main.main+0x6b"},
+		{Disasm{Offset: 0x401020, Bin: "e8dbff0000", Asm: "CALL 0x402000"}, "CALL runtime.morestack"},
+		// 0x401030 + 7 + 0x9efc9 = 0x4a0000
+		{Disasm{Offset: 0x401030, Bin: "488d05c9ef0900", Asm: "LEAQ 0x9efc9(IP), AX"}, "LEAQ go:string.*(SB), AX"},
+		{Disasm{Offset: 0x401030, Bin: "488d05d9ef0900", Asm: "LEAQ 0x9efd9(IP), AX"}, "LEAQ go:string.*(SB), AX"},
+		// an immediate after the displacement, 0x401030 + 11 + 0x9efc5
+		{Disasm{Offset: 0x401030, Bin: "48c705c5ef090001000000", Asm: "MOVQ $0x1, 0x9efc5(IP)"}, "MOVQ $0x1, go:string.*(SB)"},
+		{Disasm{Offset: 0x401030, Bin: "803dc9ef090000", Asm: "CMPB 0x9efc9(IP), $0x0"}, "CMPB go:string.*(SB), $0x0"},
+		// VEX and EVEX instructions print the displacement without (IP)
+		{Disasm{Offset: 0x401030, Bin: "c5f96f3dc8ef0900", Asm: "VMOVDQA 0x9efc8, X7"}, "VMOVDQA go:string.*(SB), X7"},
+		{Disasm{Offset: 0x401030, Bin: "c4623d0005c7ef0900", Asm: "VPSHUFB 0x9efc7, Y8, Y8"}, "VPSHUFB go:string.*(SB), Y8, Y8"},
+		{Disasm{Offset: 0x401030, Bin: "62f1fe486f05c6ef0900", Asm: "VMOVDQU64 0x9efc6, Z0"}, "VMOVDQU64 go:string.*(SB), Z0"},
+		// 0x401030 + 7 - 0x1037 = 0x400000, before any symbol
+		{Disasm{Offset: 0x401030, Bin: "488d05c9efffff", Asm: "LEAQ -0x1037(IP), AX"}, "LEAQ -0x1037(IP), AX"},
+		{Disasm{Offset: 0x401030, Bin: "e8", Asm: "CALL 0x500000"}, "CALL .+0xfefd0"},
+		{Disasm{Offset: 0x401030, Bin: "e9", Asm: "JMP 0x400000"}, "JMP .-0x1030"},
+		{Disasm{Offset: 0x401030, Bin: "f0e8", Asm: "LOCK CALL 0xfffffffffffff030"}, "LOCK CALL .-0x402000"},
+		{Disasm{Offset: 0x401030, Bin: "f0e0", Asm: "LOCK LOOPNE 0x401040"}, "LOCK LOOPNE main.main+0x40"},
+		{Disasm{Offset: 0x401030, Bin: "4883ec30", Asm: "SUBQ $0x30, SP"}, "SUBQ $0x30, SP"},
+		// ModRM 05 with a register base on a SIB-less opcode isn't RIP-relative
+		{Disasm{Offset: 0x401030, Bin: "b805000000", Asm: "MOVL $0x5, AX"}, "MOVL $0x5, AX"},
+	}
+	for _, tc := range tcs {
+		fn := Function{Asm: []Disasm{tc.insn}}
+		fn.Normalize("amd64", lookup)
+		if got := fn.Asm[0].Code(); got != tc.exp {
+			t.Errorf("%s: expected %q, got %q", tc.insn.Asm, tc.exp, got)
+		}
+	}
+}
+
+func TestNormalizeARM64(t *testing.T) {
+	lookup := func(addr int64) (string, int64, bool) {
+		if addr >= 0x1f0000 && addr < 0x200000 {
+			return "go:string.*", addr - 0x1f0000, true
+		}
+		return "", 0, false
+	}
+	// ADRP 0x1f0000 - 0xb8000 = 1277952(PC), with the low bits in the next
+	// instruction
+	adrp := Disasm{Offset: 0xb8930, Bin: "900009db", Asm: "ADRP 1277952(PC), R27"}
+	for _, tc := range []struct {
+		next Disasm
+		exp  []string
+	}{
+		{Disasm{Offset: 0xb8934, Bin: "9124037b", Asm: "ADD $2304, R27, R27"},
+			[]string{"ADRP go:string.*(SB), R27", "ADD $go:string.*(SB), R27, R27"}},
+		{Disasm{Offset: 0xb8934, Bin: "f943137a", Asm: "MOVD 1568(R27), R26"},
+			[]string{"ADRP go:string.*(SB), R27", "MOVD go:string.*(SB), R26"}},
+		{Disasm{Offset: 0xb8934, Bin: "f904bb63", Asm: "MOVD R3, 2416(R27)"},
+			[]string{"ADRP go:string.*(SB), R27", "MOVD R3, go:string.*(SB)"}},
+		{Disasm{Offset: 0xb8934, Bin: "a9401363", Asm: "LDP (R27), (R3, R4)"},
+			[]string{"ADRP go:string.*(SB), R27", "LDP go:string.*(SB), (R3, R4)"}},
+		// the next instruction doesn't use the page
+		{Disasm{Offset: 0xb8934, Bin: "9124037b", Asm: "ADD $2304, R2, R2"},
+			[]string{"ADRP 1277952(PC), R27", "ADD $2304, R2, R2"}},
+	} {
+		fn := Function{Asm: []Disasm{adrp, tc.next}}
+		fn.Normalize("arm64", lookup)
+		for i, exp := range tc.exp {
+			if got := fn.Asm[i].Code(); got != exp {
+				t.Errorf("%s: expected %q, got %q", tc.next.Asm, exp, got)
+			}
+		}
+	}
+}
+
+func TestRIPDisp(t *testing.T) {
+	for _, tc := range []struct {
+		bin  []byte
+		disp int64
+		ok   bool
+	}{
+		{[]byte{0x48, 0x8d, 0x05, 0x10, 0x00, 0x00, 0x00}, 0x10, true},
+		{[]byte{0x66, 0x0f, 0x6f, 0x05, 0xf0, 0xff, 0xff, 0xff}, -0x10, true},
+		{[]byte{0x66, 0x0f, 0x38, 0x00, 0x05, 0x20, 0x00, 0x00, 0x00}, 0x20, true},
+		{[]byte{0xc5, 0xf9, 0x6f, 0x3d, 0x0c, 0xdc, 0x03, 0x00}, 0x3dc0c, true},
+		// no memory operand, or one based on a register
+		{[]byte{0x48, 0x89, 0xc3}, 0, false},
+		{[]byte{0x48, 0x8b, 0x44, 0x24, 0x08}, 0, false},
+		// Jcc rel32 has no ModRM byte
+		{[]byte{0x0f, 0x85, 0x05, 0x00, 0x00, 0x00}, 0, false},
+	} {
+		disp, ok := ripDisp(tc.bin)
+		if disp != tc.disp || ok != tc.ok {
+			t.Errorf("%x: expected %#x %v, got %#x %v", tc.bin, tc.disp, tc.ok, disp, ok)
+		}
+	}
+}
+
+func TestSameCode(t *testing.T) {
+	// the same function, linked at different addresses
+	fnA := Function{Asm: []Disasm{
+		{Offset: 0x401000, Bin: "e8fb0f0000", Asm: "CALL 0x402000"},
+		{Offset: 0x401005, Bin: "c3", Asm: "RET"},
+	}}
+	fnB := Function{Asm: []Disasm{
+		{Offset: 0x411000, Bin: "e8fb0f0000", Asm: "CALL 0x412000"},
+		{Offset: 0x411005, Bin: "c3", Asm: "RET"},
+	}}
+	if SameCode(fnA, fnB) {
+		t.Errorf("expected different code before normalizing")
+	}
+	lookup := func(base int64) Lookup {
+		return func(addr int64) (string, int64, bool) {
+			if addr >= base+0x1000 && addr < base+0x2000 {
+				return "runtime.morestack", addr - base - 0x1000, true
+			}
+			return "", 0, false
+		}
+	}
+	fnA.Normalize("amd64", lookup(0x401000))
+	fnB.Normalize("amd64", lookup(0x411000))
+	if !SameCode(fnA, fnB) {
+		t.Errorf("expected the same code once normalized")
+	}
+}
+
+func TestSameCodeRenamed(t *testing.T) {
+	// a function moved to another package, branching to itself
+	fnA := Function{Name: "main.process", Asm: []Disasm{
+		{Norm: "JBE main.process+0x6b"},
+		{Norm: "CALL runtime.morestack"},
+		{Asm: "JMP main.process(SB)"},
+	}}
+	fnB := Function{Name: "example.com/m/sub.Process", Asm: []Disasm{
+		{Norm: "JBE example.com/m/sub.Process+0x6b"},
+		{Norm: "CALL runtime.morestack"},
+		{Asm: "JMP example.com/m/sub.Process(SB)"},
+	}}
+	if !SameCode(fnA, fnB) {
+		t.Errorf("expected the same code across names")
+	}
+	// a call to another function whose name starts like it
+	fnA.Asm[1].Norm = "CALL main.processAll"
+	fnB.Asm[1].Norm = "CALL example.com/m/sub.ProcessAll"
+	if SameCode(fnA, fnB) {
+		t.Errorf("expected different code calling different functions")
+	}
+}
@@ -0,0 +1,71 @@
+package readelf
+
+import (
+	"crypto/sha256"
+	"debug/elf"
+	"encoding/hex"
+	"io"
+	"os"
+
+	"github.com/tzneal/bincmp/internal/objfile"
+)
+
+// HashContents sets the Hash of the sections in sects, as listed from
+// filename, that have contents in the file. Compressed ELF sections are
+// hashed uncompressed.
+func HashContents(filename, arch string, sects []Section) error {
+	f, err := objfile.Open(filename, arch)
+	if objfile.IsUnknownFormat(err) {
+		// the sections came from readelf
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	readers := map[string]func() io.Reader{}
+	switch {
+	case f.ELF != nil:
+		for _, s := range f.ELF.Sections {
+			s := s
+			if s.Type != elf.SHT_NOBITS {
+				readers[s.Name] = func() io.Reader { return s.Open() }
+			}
+		}
+	case f.MachO != nil:
+		for _, s := range f.MachO.Sections {
+			s := s
+			readers[s.Seg+","+s.Name] = func() io.Reader { return s.Open() }
+		}
+	case f.PE != nil:
+		for _, s := range f.PE.Sections {
+			s := s
+			readers[s.Name] = func() io.Reader { return s.Open() }
+		}
+	case f.Wasm != nil:
+		// module sections are read from the file by offset
+		r, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		for _, s := range f.Wasm.Sections {
+			s := s
+			readers[s.Name] = func() io.Reader { return io.NewSectionReader(r, s.Offset, s.Size) }
+		}
+	}
+
+	for i, s := range sects {
+		open, ok := readers[s.Name]
+		if !ok || s.FileSize == 0 {
+			continue
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, open()); err != nil {
+			return err
+		}
+		sects[i].Hash = hex.EncodeToString(h.Sum(nil))
+	}
+	return nil
+}
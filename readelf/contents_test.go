@@ -0,0 +1,37 @@
+package readelf
+
+import (
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+func TestHashContents(t *testing.T) {
+	hashes := func(src string) map[string]string {
+		obj := elftest.CBinary(t, src, "-c")
+		sects, err := ListSections(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := HashContents(obj, "", sects); err != nil {
+			t.Fatal(err)
+		}
+		ret := map[string]string{}
+		for _, s := range sects {
+			ret[s.Name] = s.Hash
+		}
+		return ret
+	}
+	a := hashes("int x[4] = {1, 2, 3, 4};\nint y[4];\nint f(void) { return 1; }\n")
+	b := hashes("int x[4] = {1, 2, 3, 5};\nint y[4];\nint f(void) { return 1; }\n")
+
+	if a[".data"] == "" || a[".data"] == b[".data"] {
+		t.Errorf("expected different .data hashes, got %q and %q", a[".data"], b[".data"])
+	}
+	if a[".text"] == "" || a[".text"] != b[".text"] {
+		t.Errorf("expected the same .text hash, got %q and %q", a[".text"], b[".text"])
+	}
+	if a[".bss"] != "" {
+		t.Errorf("expected no hash for .bss, got %q", a[".bss"])
+	}
+}
@@ -0,0 +1,95 @@
+package readelf
+
+import (
+	"debug/elf"
+	"strings"
+)
+
+// ELFSections reads the section headers of an ELF file directly, without
+// relying on an external readelf binary. The null section is skipped, as
+// it is by the readelf parser.
+func ELFSections(f *elf.File) []Section {
+	ret := make([]Section, 0, len(f.Sections))
+	for _, s := range f.Sections {
+		if s.Name == "" {
+			continue
+		}
+		var fileSize int64
+		if s.Type != elf.SHT_NOBITS {
+			fileSize = int64(s.FileSize)
+		}
+		ret = append(ret, Section{
+			Name:    s.Name,
+			Type:    elfSectionType(s.Type),
+			Address: int64(s.Addr),
+			Offset:  int64(s.Offset),
+			// s.FileSize is sh_size, s.Size is the uncompressed size of
+			// compressed sections
+			Size:    int64(s.FileSize),
+			EntSize: int64(s.Entsize),
+			Flags:   elfSectionFlags(s.Flags),
+			Link:    int64(s.Link),
+			Info:    int64(s.Info),
+			Align:   int64(s.Addralign),
+
+			FileSize: fileSize,
+		})
+	}
+	return ret
+}
+
+// elfSectionType returns the section type as readelf names it.
+func elfSectionType(t elf.SectionType) string {
+	switch t {
+	case elf.SHT_GNU_VERSYM:
+		return "VERSYM"
+	case elf.SHT_GNU_VERDEF:
+		return "VERDEF"
+	case elf.SHT_GNU_VERNEED:
+		return "VERNEED"
+	case 0x70000001: // SHT_X86_64_UNWIND
+		return "X86_64_UNWIND"
+	}
+	return strings.TrimPrefix(t.String(), "SHT_")
+}
+
+// elfFlagKeys are the readelf flag letters, in the order readelf prints them.
+var elfFlagKeys = []struct {
+	flag elf.SectionFlag
+	key  byte
+}{
+	{elf.SHF_WRITE, 'W'},
+	{elf.SHF_ALLOC, 'A'},
+	{elf.SHF_EXECINSTR, 'X'},
+	{elf.SHF_MERGE, 'M'},
+	{elf.SHF_STRINGS, 'S'},
+	{elf.SHF_INFO_LINK, 'I'},
+	{elf.SHF_LINK_ORDER, 'L'},
+	{elf.SHF_OS_NONCONFORMING, 'O'},
+	{elf.SHF_GROUP, 'G'},
+	{elf.SHF_TLS, 'T'},
+	{elf.SHF_COMPRESSED, 'C'},
+	{0x200000, 'R'},   // SHF_GNU_RETAIN
+	{0x10000000, 'l'}, // SHF_X86_64_LARGE
+	{0x80000000, 'E'}, // SHF_EXCLUDE
+}
+
+// elfSectionFlags returns the flags in the letter form used by readelf -S.
+func elfSectionFlags(f elf.SectionFlag) string {
+	var sb strings.Builder
+	for _, k := range elfFlagKeys {
+		if f&k.flag != 0 {
+			sb.WriteByte(k.key)
+			f &^= k.flag
+		}
+	}
+	switch {
+	case f&elf.SHF_MASKOS != 0:
+		sb.WriteByte('o')
+	case f&elf.SHF_MASKPROC != 0:
+		sb.WriteByte('p')
+	case f != 0:
+		sb.WriteByte('x')
+	}
+	return sb.String()
+}
@@ -0,0 +1,118 @@
+package readelf
+
+import (
+	"bytes"
+	"debug/elf"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+var fixtureTypes = map[string]elf.SectionType{
+	"PROGBITS":   elf.SHT_PROGBITS,
+	"NOTE":       elf.SHT_NOTE,
+	"GNU_HASH":   elf.SHT_GNU_HASH,
+	"DYNSYM":     elf.SHT_DYNSYM,
+	"STRTAB":     elf.SHT_STRTAB,
+	"VERSYM":     elf.SHT_GNU_VERSYM,
+	"VERNEED":    elf.SHT_GNU_VERNEED,
+	"RELA":       elf.SHT_RELA,
+	"INIT_ARRAY": elf.SHT_INIT_ARRAY,
+	"FINI_ARRAY": elf.SHT_FINI_ARRAY,
+	"DYNAMIC":    elf.SHT_DYNAMIC,
+	"NOBITS":     elf.SHT_NOBITS,
+}
+
+// fixtureELF builds an ELF file with the section headers from the readelf
+// output. The .shstrtab section is written by elftest itself.
+func fixtureELF(t *testing.T, sects []Section) *elf.File {
+	t.Helper()
+	var f elftest.File
+	for _, s := range sects {
+		if s.Name == ".shstrtab" {
+			continue
+		}
+		typ, ok := fixtureTypes[s.Type]
+		if !ok {
+			t.Fatalf("unhandled section type %s", s.Type)
+		}
+		var flags elf.SectionFlag
+		for _, k := range elfFlagKeys {
+			if strings.IndexByte(s.Flags, k.key) >= 0 {
+				flags |= k.flag
+			}
+		}
+		f.AddSection(elftest.Section{
+			Name:    s.Name,
+			Type:    typ,
+			Flags:   flags,
+			Addr:    uint64(s.Address),
+			Offset:  uint64(s.Offset),
+			Size:    uint64(s.Size),
+			Link:    uint32(s.Link),
+			Info:    uint32(s.Info),
+			Align:   uint64(s.Align),
+			EntSize: uint64(s.EntSize),
+		})
+	}
+	ef, err := elf.NewFile(bytes.NewReader(f.Bytes()))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	return ef
+}
+
+func TestELFSectionsParity(t *testing.T) {
+	fromReadelf, err := parseListSections(strings.NewReader(readelfFixture))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	native := ELFSections(fixtureELF(t, fromReadelf))
+	if len(native) != len(fromReadelf) {
+		t.Fatalf("expected %d sections, got %d", len(fromReadelf), len(native))
+	}
+	for i := range fromReadelf {
+		if fromReadelf[i].Name == ".shstrtab" {
+			continue
+		}
+		if fromReadelf[i] != native[i] {
+			t.Errorf("expected %v, got %v", fromReadelf[i], native[i])
+		}
+	}
+}
+
+func TestELFSectionsMatchReadelf(t *testing.T) {
+	if !haveReadelf() {
+		t.Skip("readelf not installed")
+	}
+	exe := elftest.GoBinary(t, "package main\n\nfunc main() {}\n")
+	out, err := exec.Command("readelf", "-S", exe).Output()
+	if err != nil {
+		t.Skipf("running readelf: %s", err)
+	}
+	fromReadelf, err := parseListSections(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	f, err := elf.Open(exe)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	defer f.Close()
+	native := ELFSections(f)
+	if len(native) != len(fromReadelf) {
+		t.Fatalf("expected %d sections, got %d", len(fromReadelf), len(native))
+	}
+	for i := range fromReadelf {
+		// readelf truncates long names
+		exp := fromReadelf[i]
+		if strings.HasPrefix(native[i].Name, strings.TrimSuffix(exp.Name, "[...]")) {
+			exp.Name = native[i].Name
+		}
+		if exp != native[i] {
+			t.Errorf("expected %v, got %v", fromReadelf[i], native[i])
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package readelf
+
+import (
+	"debug/macho"
+	"fmt"
+	"strings"
+)
+
+// machoSectionTypes are the names of the Mach-O section types, indexed by
+// the low byte of the section flags.
+var machoSectionTypes = []string{
+	"REGULAR",
+	"ZEROFILL",
+	"CSTRING_LITERALS",
+	"4BYTE_LITERALS",
+	"8BYTE_LITERALS",
+	"LITERAL_POINTERS",
+	"NON_LAZY_SYMBOL_POINTERS",
+	"LAZY_SYMBOL_POINTERS",
+	"SYMBOL_STUBS",
+	"MOD_INIT_FUNC_POINTERS",
+	"MOD_TERM_FUNC_POINTERS",
+	"COALESCED",
+	"GB_ZEROFILL",
+	"INTERPOSING",
+	"16BYTE_LITERALS",
+	"DTRACE_DOF",
+	"LAZY_DYLIB_SYMBOL_POINTERS",
+	"THREAD_LOCAL_REGULAR",
+	"THREAD_LOCAL_ZEROFILL",
+	"THREAD_LOCAL_VARIABLES",
+	"THREAD_LOCAL_VARIABLE_POINTERS",
+	"THREAD_LOCAL_INIT_FUNCTION_POINTERS",
+	"INIT_FUNC_OFFSETS",
+}
+
+const machoInstructions = 0x80000400 // S_ATTR_PURE_INSTRUCTIONS|S_ATTR_SOME_INSTRUCTIONS
+
+// MachOSections reads the section headers of a Mach-O file. Section names
+// are only unique within a segment, so they are reported in the
+// "segment,section" form, e.g. "__TEXT,__text".
+func MachOSections(f *macho.File) []Section {
+	ret := make([]Section, 0, len(f.Sections))
+	for _, s := range f.Sections {
+		typ := fmt.Sprintf("0x%x", s.Flags&0xff)
+		if int(s.Flags&0xff) < len(machoSectionTypes) {
+			typ = machoSectionTypes[s.Flags&0xff]
+		}
+		flags := ""
+		if s.Flags&machoInstructions != 0 {
+			flags = "X"
+		}
+		fileSize := int64(s.Size)
+		if strings.HasSuffix(typ, "ZEROFILL") {
+			fileSize = 0
+		}
+		ret = append(ret, Section{
+			Name:    s.Seg + "," + s.Name,
+			Type:    typ,
+			Address: int64(s.Addr),
+			Offset:  int64(s.Offset),
+			Size:    int64(s.Size),
+			Flags:   flags,
+			Align:   int64(1) << s.Align,
+
+			FileSize: fileSize,
+		})
+	}
+	return ret
+}
@@ -0,0 +1,38 @@
+package readelf
+
+import (
+	"debug/macho"
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+func TestMachOSections(t *testing.T) {
+	t.Setenv("GOOS", "darwin")
+	t.Setenv("GOARCH", "amd64")
+	t.Setenv("CGO_ENABLED", "0")
+	f, err := macho.Open(elftest.GoBinary(t, "package main\n\nfunc main() {}\n"))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	defer f.Close()
+	sects := MachOSections(f)
+	if len(sects) != len(f.Sections) {
+		t.Fatalf("expected %d sections, got %d", len(f.Sections), len(sects))
+	}
+
+	byName := map[string]Section{}
+	for _, s := range sects {
+		byName[s.Name] = s
+	}
+	text, ok := byName["__TEXT,__text"]
+	if !ok {
+		t.Fatalf("expected a __TEXT,__text section in %v", sects)
+	}
+	if text.Type != "REGULAR" || text.Flags != "X" || text.Size == 0 {
+		t.Errorf("unexpected text section %v", text)
+	}
+	if bss := byName["__DATA,__bss"]; bss.Type != "ZEROFILL" {
+		t.Errorf("expected a zerofill bss section, got %v", bss)
+	}
+}
@@ -0,0 +1,70 @@
+package readelf
+
+import "debug/pe"
+
+// PESections reads the section headers of a PE file. Size is the virtual
+// size of the section once loaded, which for Go binaries includes .bss in
+// .data, while FileSize is the raw size stored in the file, rounded up to
+// the file alignment.
+func PESections(f *pe.File) []Section {
+	var base int64
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		base = int64(oh.ImageBase)
+	case *pe.OptionalHeader64:
+		base = int64(oh.ImageBase)
+	}
+
+	ret := make([]Section, 0, len(f.Sections))
+	for _, s := range f.Sections {
+		size := s.VirtualSize
+		if size == 0 {
+			// object files only have a raw size
+			size = s.Size
+		}
+		sect := Section{
+			Name:     s.Name,
+			Type:     peSectionType(s.Characteristics),
+			Offset:   int64(s.Offset),
+			Size:     int64(size),
+			FileSize: int64(s.Size),
+			Flags:    peSectionFlags(s.Characteristics),
+		}
+		if s.VirtualAddress != 0 {
+			sect.Address = base + int64(s.VirtualAddress)
+		}
+		// IMAGE_SCN_ALIGN_* are only set in object files
+		if a := (s.Characteristics >> 20) & 0xf; a != 0 {
+			sect.Align = 1 << (a - 1)
+		}
+		ret = append(ret, sect)
+	}
+	return ret
+}
+
+func peSectionType(c uint32) string {
+	switch {
+	case c&pe.IMAGE_SCN_CNT_CODE != 0:
+		return "CODE"
+	case c&pe.IMAGE_SCN_CNT_INITIALIZED_DATA != 0:
+		return "INITIALIZED_DATA"
+	case c&pe.IMAGE_SCN_CNT_UNINITIALIZED_DATA != 0:
+		return "UNINITIALIZED_DATA"
+	}
+	return ""
+}
+
+// peSectionFlags maps the memory characteristics to readelf flag letters.
+func peSectionFlags(c uint32) string {
+	flags := ""
+	if c&pe.IMAGE_SCN_MEM_WRITE != 0 {
+		flags += "W"
+	}
+	if c&pe.IMAGE_SCN_MEM_DISCARDABLE == 0 {
+		flags += "A"
+	}
+	if c&pe.IMAGE_SCN_MEM_EXECUTE != 0 {
+		flags += "X"
+	}
+	return flags
+}
@@ -0,0 +1,33 @@
+package readelf
+
+import (
+	"debug/pe"
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+func TestPESections(t *testing.T) {
+	t.Setenv("GOOS", "windows")
+	t.Setenv("GOARCH", "amd64")
+	t.Setenv("CGO_ENABLED", "0")
+	f, err := pe.Open(elftest.GoBinary(t, "package main\n\nfunc main() {}\n"))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	defer f.Close()
+
+	byName := map[string]Section{}
+	for _, s := range PESections(f) {
+		byName[s.Name] = s
+	}
+	text := byName[".text"]
+	if text.Type != "CODE" || text.Flags != "AX" || text.Address == 0 {
+		t.Errorf("unexpected text section %v", text)
+	}
+	// .data holds .bss after its raw data
+	data := byName[".data"]
+	if data.Size <= data.FileSize || data.FileSize%512 != 0 {
+		t.Errorf("expected virtual size past the raw size, got %v", data)
+	}
+}
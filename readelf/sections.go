@@ -9,6 +9,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/tzneal/bincmp/internal/objfile"
 )
 
 // Section is a section of a binary extracted via readelf
@@ -19,15 +21,63 @@ type Section struct {
 	Offset  int64
 	Size    int64
 	EntSize int64
+	Flags   string // in readelf's letter form, e.g. "AX"
+	Link    int64
+	Info    int64
+	Align   int64
+	// FileSize is the number of bytes the section occupies in the file,
+	// which is zero for .bss like sections.
+	FileSize int64
+	// Hash identifies the contents of the section, set by HashContents.
+	Hash string
 }
 
 func (s Section) IsEmpty() bool {
 	return len(s.Name) == 0 && s.Size == 0
 }
 
-// ListSections parses the output of "readelf -s" to get section
-// information.
+// ListSections reads the section headers of an ELF, Mach-O, PE or
+// WebAssembly binary. Files in other formats are handed to readelf if it is installed.
 func ListSections(filename string) ([]Section, error) {
+	return ListSectionsArch(filename, "")
+}
+
+// ListSectionsArch is like ListSections, but reads the slice for arch out of
+// Mach-O universal binaries.
+func ListSectionsArch(filename, arch string) ([]Section, error) {
+	f, err := objfile.Open(filename, arch)
+	if err != nil {
+		if objfile.IsUnknownFormat(err) && haveReadelf() {
+			return ListSectionsReadelf(filename)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return fileSections(f)
+}
+
+func fileSections(f *objfile.File) ([]Section, error) {
+	switch {
+	case f.ELF != nil:
+		return ELFSections(f.ELF), nil
+	case f.MachO != nil:
+		return MachOSections(f.MachO), nil
+	case f.PE != nil:
+		return PESections(f.PE), nil
+	case f.Wasm != nil:
+		return WasmSections(f.Wasm), nil
+	}
+	return nil, fmt.Errorf("unsupported object file")
+}
+
+func haveReadelf() bool {
+	_, err := exec.LookPath("readelf")
+	return err == nil
+}
+
+// ListSectionsReadelf parses the output of "readelf -S" to get section
+// information.
+func ListSectionsReadelf(filename string) ([]Section, error) {
 	args := []string{"-S", filename}
 	cmd := exec.Command("readelf", args...)
 	p, err := cmd.StdoutPipe()
@@ -89,11 +139,18 @@ func parseListSections(r io.Reader) ([]Section, error) {
 			Address: parseHex(line1[4]),
 			Offset:  parseHex(line1[5]),
 			Size:    parseHex(line2[1]),
-			EntSize: parseHex(line2[2])}
+			EntSize: parseHex(line2[2]),
+			Flags:   line2[3],
+			Link:    parseDec(line2[4]),
+			Info:    parseDec(line2[5]),
+			Align:   parseDec(line2[6])}
 
 		if s.Name == "" {
 			continue
 		}
+		if s.Type != "NOBITS" {
+			s.FileSize = s.Size
+		}
 		ret = append(ret, s)
 	}
 	return ret, nil
@@ -107,3 +164,12 @@ func parseHex(s string) int64 {
 	}
 	return i
 }
+
+func parseDec(s string) int64 {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		log.Printf("error parsing decimal %s: %s", s, err)
+		return -1
+	}
+	return i
+}
@@ -5,8 +5,7 @@ import (
 	"testing"
 )
 
-func TestListSections(t *testing.T) {
-	inp := `There are 29 section headers, starting at offset 0x1e738:
+const readelfFixture = `There are 29 section headers, starting at offset 0x1e738:
 
 Section Headers:
   [Nr] Name              Type             Address           Offset
@@ -74,7 +73,9 @@ Key to Flags:
   I (info), L (link order), G (group), T (TLS), E (exclude), x (unknown)
   O (extra OS processing required) o (OS specific), p (processor specific)
 `
-	r := strings.NewReader(inp)
+
+func TestListSections(t *testing.T) {
+	r := strings.NewReader(readelfFixture)
 	sects, err := parseListSections(r)
 	if err != nil {
 		t.Errorf("expected no error, got %s", err)
@@ -89,7 +90,13 @@ Key to Flags:
 		Address: 0x400238,
 		Offset:  0x238,
 		Size:    0x1c,
-		EntSize: 0x0}
+		EntSize: 0x0,
+		Flags:   "A",
+		Link:    0,
+		Info:    0,
+		Align:   1,
+
+		FileSize: 0x1c}
 	if sects[0] != exp {
 		t.Errorf("expected %v, got %v", exp, sects[0])
 	}
@@ -0,0 +1,28 @@
+package readelf
+
+import (
+	"strings"
+
+	"github.com/tzneal/bincmp/wasm"
+)
+
+// WasmSections lists the sections of a WebAssembly module. Custom sections
+// are named after their custom name and have the type CUSTOM, the others
+// are named and typed after their id, e.g. "code" and CODE.
+func WasmSections(m *wasm.Module) []Section {
+	ret := make([]Section, 0, len(m.Sections))
+	for _, s := range m.Sections {
+		typ := "CUSTOM"
+		if s.ID != wasm.SectionCustom {
+			typ = strings.ToUpper(s.Name)
+		}
+		ret = append(ret, Section{
+			Name:     s.Name,
+			Type:     typ,
+			Offset:   s.Offset,
+			Size:     s.Size,
+			FileSize: s.Size,
+		})
+	}
+	return ret
+}
@@ -0,0 +1,159 @@
+package wasm
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errTruncated = errors.New("unexpected end of section")
+
+// decoder reads the WebAssembly binary encoding. The first error is kept
+// in err and all later reads return zero values.
+type decoder struct {
+	buf []byte
+	off int
+	err error
+}
+
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+	d.off = len(d.buf)
+}
+
+func (d *decoder) byte() byte {
+	if d.off >= len(d.buf) {
+		d.fail(errTruncated)
+		return 0
+	}
+	b := d.buf[d.off]
+	d.off++
+	return b
+}
+
+func (d *decoder) skip(n int) {
+	if n < 0 || d.off+n > len(d.buf) {
+		d.fail(errTruncated)
+		return
+	}
+	d.off += n
+}
+
+func (d *decoder) uleb() uint32 {
+	var v uint64
+	for shift := uint(0); ; shift += 7 {
+		b := d.byte()
+		if d.err != nil {
+			return 0
+		}
+		if shift < 64 {
+			v |= uint64(b&0x7f) << shift
+		}
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	return uint32(v)
+}
+
+func (d *decoder) name() string {
+	n := int(d.uleb())
+	start := d.off
+	d.skip(n)
+	if d.err != nil {
+		return ""
+	}
+	return string(d.buf[start:d.off])
+}
+
+func (d *decoder) limits() {
+	flags := d.byte()
+	d.uleb()
+	if flags&1 != 0 {
+		d.uleb()
+	}
+}
+
+func (d *decoder) imports() []Import {
+	n := d.uleb()
+	var ret []Import
+	for i := uint32(0); i < n && d.err == nil; i++ {
+		imp := Import{Module: d.name(), Name: d.name(), Kind: Kind(d.byte())}
+		switch imp.Kind {
+		case KindFunc:
+			d.uleb()
+		case KindTable:
+			d.byte()
+			d.limits()
+		case KindMemory:
+			d.limits()
+		case KindGlobal:
+			d.byte()
+			d.byte()
+		case KindTag:
+			d.byte()
+			d.uleb()
+		default:
+			d.fail(fmt.Errorf("unknown import kind %d", imp.Kind))
+		}
+		ret = append(ret, imp)
+	}
+	return ret
+}
+
+func (d *decoder) exports() []Export {
+	n := d.uleb()
+	var ret []Export
+	for i := uint32(0); i < n && d.err == nil; i++ {
+		ret = append(ret, Export{Name: d.name(), Kind: Kind(d.byte()), Index: d.uleb()})
+	}
+	return ret
+}
+
+func (d *decoder) code(firstIndex uint32) []Function {
+	n := d.uleb()
+	var ret []Function
+	for i := uint32(0); i < n && d.err == nil; i++ {
+		size := d.uleb()
+		fn := Function{Index: firstIndex + i, Offset: int64(d.off), Size: int64(size)}
+		d.skip(int(size))
+		ret = append(ret, fn)
+	}
+	return ret
+}
+
+// nameFunctions is the id of the function names subsection
+const nameFunctions = 1
+
+// nameSection reads the function names out of the "name" custom section.
+// Malformed name sections are ignored rather than failing the whole module,
+// as the names are only informational.
+func (d *decoder) nameSection() map[uint32]string {
+	var funcs map[uint32]string
+	for d.off < len(d.buf) && d.err == nil {
+		id := d.byte()
+		size := int(d.uleb())
+		end := d.off + size
+		if d.err != nil || end > len(d.buf) {
+			break
+		}
+		if id == nameFunctions {
+			sub := &decoder{buf: d.buf[:end], off: d.off}
+			funcs = sub.nameMap()
+		}
+		d.off = end
+	}
+	d.err = nil
+	return funcs
+}
+
+func (d *decoder) nameMap() map[uint32]string {
+	n := d.uleb()
+	ret := map[uint32]string{}
+	for i := uint32(0); i < n && d.err == nil; i++ {
+		idx := d.uleb()
+		ret[idx] = d.name()
+	}
+	return ret
+}
@@ -0,0 +1,175 @@
+// Package wasm parses the parts of a WebAssembly module needed to compare
+// binaries: the sections, function bodies, imports and exports.
+package wasm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Magic is the start of every binary WebAssembly module.
+const Magic = "\x00asm"
+
+// Section ids
+const (
+	SectionCustom    = 0
+	SectionType      = 1
+	SectionImport    = 2
+	SectionFunction  = 3
+	SectionTable     = 4
+	SectionMemory    = 5
+	SectionGlobal    = 6
+	SectionExport    = 7
+	SectionStart     = 8
+	SectionElement   = 9
+	SectionCode      = 10
+	SectionData      = 11
+	SectionDataCount = 12
+	SectionTag       = 13
+)
+
+var sectionNames = []string{"custom", "type", "import", "function", "table",
+	"memory", "global", "export", "start", "element", "code", "data",
+	"datacount", "tag"}
+
+// Kind is the kind of an imported or exported entity
+type Kind byte
+
+const (
+	KindFunc Kind = iota
+	KindTable
+	KindMemory
+	KindGlobal
+	KindTag
+)
+
+var kindNames = []string{"func", "table", "memory", "global", "tag"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("Kind(%d)", k)
+}
+
+// Section is a section of the module. Custom sections are named after their
+// custom name, the others after their id, e.g. "code".
+type Section struct {
+	ID     byte
+	Name   string
+	Offset int64 // file offset of the section contents
+	Size   int64
+}
+
+// Function is the body of a function defined in the code section. Index is
+// the index in the function index space, which starts with the imported
+// functions.
+type Function struct {
+	Index  uint32
+	Name   string
+	Offset int64
+	Size   int64
+}
+
+type Import struct {
+	Module string
+	Name   string
+	Kind   Kind
+}
+
+type Export struct {
+	Name  string
+	Kind  Kind
+	Index uint32
+}
+
+// Module is a parsed WebAssembly module.
+type Module struct {
+	Sections  []Section
+	Functions []Function
+	Imports   []Import
+	Exports   []Export
+}
+
+// Section returns the first section with the given name, or nil.
+func (m *Module) Section(name string) *Section {
+	for i := range m.Sections {
+		if m.Sections[i].Name == name {
+			return &m.Sections[i]
+		}
+	}
+	return nil
+}
+
+var errBadMagic = errors.New("not a WebAssembly module")
+
+// NewModule parses the module in r.
+func NewModule(r io.ReaderAt) (*Module, error) {
+	buf, err := io.ReadAll(io.NewSectionReader(r, 0, math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	return Parse(buf)
+}
+
+// Parse parses a module held in memory.
+func Parse(buf []byte) (*Module, error) {
+	if len(buf) < 8 || !bytes.Equal(buf[:4], []byte(Magic)) {
+		return nil, errBadMagic
+	}
+	m := &Module{}
+	d := &decoder{buf: buf, off: 8}
+	var names map[uint32]string
+	var nImportedFuncs uint32
+	for d.off < len(buf) {
+		id := d.byte()
+		size := d.uleb()
+		start := d.off
+		if d.err != nil || start+int(size) > len(buf) || start+int(size) < start {
+			return nil, fmt.Errorf("wasm: section at offset %d: truncated", start)
+		}
+		sd := &decoder{buf: buf[:start+int(size)], off: start}
+		sect := Section{ID: id, Offset: int64(start), Size: int64(size)}
+		if int(id) < len(sectionNames) {
+			sect.Name = sectionNames[id]
+		} else {
+			sect.Name = fmt.Sprintf("section%d", id)
+		}
+
+		switch id {
+		case SectionCustom:
+			sect.Name = sd.name()
+			if sect.Name == "name" {
+				names = sd.nameSection()
+			}
+		case SectionImport:
+			m.Imports = sd.imports()
+			for _, imp := range m.Imports {
+				if imp.Kind == KindFunc {
+					nImportedFuncs++
+				}
+			}
+		case SectionExport:
+			m.Exports = sd.exports()
+		case SectionCode:
+			m.Functions = sd.code(nImportedFuncs)
+		}
+		if sd.err != nil {
+			return nil, fmt.Errorf("wasm: %s section: %s", sect.Name, sd.err)
+		}
+		m.Sections = append(m.Sections, sect)
+		d.off = start + int(size)
+	}
+
+	for i, fn := range m.Functions {
+		if n, ok := names[fn.Index]; ok {
+			m.Functions[i].Name = n
+		} else {
+			m.Functions[i].Name = fmt.Sprintf("func[%d]", fn.Index)
+		}
+	}
+	return m, nil
+}
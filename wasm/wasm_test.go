@@ -0,0 +1,124 @@
+package wasm
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/tzneal/bincmp/internal/elftest"
+)
+
+func section(id byte, payload ...byte) []byte {
+	return append([]byte{id, byte(len(payload))}, payload...)
+}
+
+func name(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+func cat(parts ...[]byte) []byte {
+	var ret []byte
+	for _, p := range parts {
+		ret = append(ret, p...)
+	}
+	return ret
+}
+
+// testModule imports env.log and defines helper and run, exporting run and
+// its memory.
+func testModule() []byte {
+	return cat(
+		[]byte(Magic), []byte{1, 0, 0, 0},
+		section(SectionType, 1, 0x60, 0, 0),
+		section(SectionImport, cat([]byte{1}, name("env"), name("log"), []byte{0, 0})...),
+		section(SectionFunction, 2, 0, 0),
+		section(SectionMemory, 1, 0, 1),
+		section(SectionExport, cat([]byte{2}, name("run"), []byte{0, 2}, name("mem"), []byte{2, 0})...),
+		section(SectionCode, 2, 2, 0, 0x0b, 4, 0, 0x10, 0, 0x0b),
+		section(SectionCustom, cat(name("name"), []byte{1, 14, 2}, []byte{1}, name("helper"), []byte{2}, name("run"))...),
+	)
+}
+
+func TestParse(t *testing.T) {
+	m, err := Parse(testModule())
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	var names []string
+	for _, s := range m.Sections {
+		names = append(names, s.Name)
+	}
+	if exp := "[type import function memory export code name]"; fmt.Sprint(names) != exp {
+		t.Errorf("expected sections %s, got %s", exp, fmt.Sprint(names))
+	}
+
+	code := m.Section("code")
+	exp := []Function{
+		{Index: 1, Name: "helper", Offset: code.Offset + 2, Size: 2},
+		{Index: 2, Name: "run", Offset: code.Offset + 5, Size: 4},
+	}
+	if len(m.Functions) != len(exp) {
+		t.Fatalf("expected %d functions, got %d", len(exp), len(m.Functions))
+	}
+	for i := range exp {
+		if m.Functions[i] != exp[i] {
+			t.Errorf("expected %v, got %v", exp[i], m.Functions[i])
+		}
+	}
+
+	if len(m.Imports) != 1 || m.Imports[0] != (Import{Module: "env", Name: "log", Kind: KindFunc}) {
+		t.Errorf("unexpected imports %v", m.Imports)
+	}
+	expExports := []Export{{Name: "run", Kind: KindFunc, Index: 2}, {Name: "mem", Kind: KindMemory}}
+	if len(m.Exports) != len(expExports) {
+		t.Fatalf("expected %d exports, got %d", len(expExports), len(m.Exports))
+	}
+	for i := range expExports {
+		if m.Exports[i] != expExports[i] {
+			t.Errorf("expected %v, got %v", expExports[i], m.Exports[i])
+		}
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	mod := testModule()
+	if _, err := Parse(mod[:len(mod)-30]); err == nil {
+		t.Errorf("expected an error for a truncated module")
+	}
+	if _, err := Parse([]byte("\x7fELF\x02\x01\x01\x00")); err != errBadMagic {
+		t.Errorf("expected %s, got %v", errBadMagic, err)
+	}
+}
+
+func TestParseGo(t *testing.T) {
+	t.Setenv("GOOS", "js")
+	t.Setenv("GOARCH", "wasm")
+	buf, err := os.ReadFile(elftest.GoBinary(t, "package main\n\nfunc main() { println(\"hi\") }\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Parse(buf)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	found := false
+	for _, fn := range m.Functions {
+		if fn.Name == "main.main" {
+			found = fn.Size > 0
+		}
+	}
+	if !found {
+		t.Errorf("expected a body for main.main")
+	}
+	if m.Section("code") == nil || m.Section("name") == nil {
+		t.Errorf("expected code and name sections, got %v", m.Sections)
+	}
+	gojs := false
+	for _, imp := range m.Imports {
+		gojs = gojs || imp.Module == "gojs"
+	}
+	if !gojs {
+		t.Errorf("expected imports from gojs, got %v", m.Imports)
+	}
+}